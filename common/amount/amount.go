@@ -0,0 +1,123 @@
+// Package amount handles the amounts of money in google.type.Money exactly, without going through floating point.
+package amount
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	money "google.golang.org/genproto/googleapis/type/money"
+)
+
+const nanosPerUnit = 1000000000
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// New creates an amount of money in the currency, e.g. New("USD", 1300, 500000000) is 1300.50 USD.
+func New(currencyCode string, units int64, nanos int32) *money.Money {
+	return &money.Money{CurrencyCode: currencyCode, Units: units, Nanos: nanos}
+}
+
+// Check checks the amount is well-formed, returns the description of the problem or "" if it is valid.
+// The currency code must be a 3-letter ISO 4217 code, and the nanos must be in range with the same sign as the units.
+func Check(m *money.Money) string {
+	switch {
+	case !currencyCodePattern.MatchString(m.CurrencyCode):
+		return "currency code must be a 3-letter ISO 4217 code"
+	case m.Nanos <= -nanosPerUnit || m.Nanos >= nanosPerUnit:
+		return "nanos must be between -999,999,999 and 999,999,999"
+	case m.Units > 0 && m.Nanos < 0, m.Units < 0 && m.Nanos > 0:
+		return "units and nanos must have the same sign"
+	}
+	return ""
+}
+
+// Compare compares two amounts in the same currency, returns -1, 0 or 1 if a is less than, equal to or greater than b.
+func Compare(a, b *money.Money) int {
+	switch {
+	case a.GetUnits() < b.GetUnits():
+		return -1
+	case a.GetUnits() > b.GetUnits():
+		return 1
+	case a.GetNanos() < b.GetNanos():
+		return -1
+	case a.GetNanos() > b.GetNanos():
+		return 1
+	}
+	return 0
+}
+
+// Add adds two amounts exactly.
+// Returns an error if the currencies are different or the sum overflows.
+func Add(a, b *money.Money) (*money.Money, error) {
+	if a.CurrencyCode != b.CurrencyCode {
+		return nil, fmt.Errorf("can't add %s to %s", b.CurrencyCode, a.CurrencyCode)
+	}
+	if (b.Units > 0 && a.Units > math.MaxInt64-b.Units) || (b.Units < 0 && a.Units < math.MinInt64-b.Units) {
+		return nil, fmt.Errorf("%s + %s overflows", Format(a), Format(b))
+	}
+	units := a.Units + b.Units
+	nanos := int64(a.Nanos) + int64(b.Nanos)
+
+	// Carry the nanos over to the units, then make the signs of the units and the nanos agree.
+	switch {
+	case nanos >= nanosPerUnit:
+		units, nanos = units+1, nanos-nanosPerUnit
+	case nanos <= -nanosPerUnit:
+		units, nanos = units-1, nanos+nanosPerUnit
+	}
+	switch {
+	case units > 0 && nanos < 0:
+		units, nanos = units-1, nanos+nanosPerUnit
+	case units < 0 && nanos > 0:
+		units, nanos = units+1, nanos-nanosPerUnit
+	}
+	return New(a.CurrencyCode, units, int32(nanos)), nil
+}
+
+// AddToTotals adds the amount to the totals, which have one amount per currency.
+// The totals are not modified, the new totals are returned.
+// Returns an error if the amount is missing or the sum overflows.
+func AddToTotals(totals []*money.Money, m *money.Money) ([]*money.Money, error) {
+	if m == nil {
+		return nil, fmt.Errorf("no amount to add")
+	}
+	next := make([]*money.Money, 0, len(totals)+1)
+	added := false
+	for _, total := range totals {
+		if total.CurrencyCode == m.CurrencyCode {
+			sum, err := Add(total, m)
+			if err != nil {
+				return nil, err
+			}
+			total, added = sum, true
+		}
+		next = append(next, total)
+	}
+	if !added {
+		next = append(next, proto.Clone(m).(*money.Money))
+	}
+	return next, nil
+}
+
+// Format formats the amount as a decimal number with the currency code, e.g. "1300.50 USD".
+// At least 2 fractional digits are kept.
+func Format(m *money.Money) string {
+	if m == nil {
+		return ""
+	}
+	sign := ""
+	units, nanos := uint64(m.Units), uint64(m.Nanos)
+	if m.Units < 0 || m.Nanos < 0 {
+		sign = "-"
+		units, nanos = uint64(-m.Units), uint64(-m.Nanos)
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	return sign + strconv.FormatUint(units, 10) + "." + fraction + " " + m.CurrencyCode
+}
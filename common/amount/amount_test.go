@@ -0,0 +1,84 @@
+package amount
+
+import (
+	"testing"
+
+	money "google.golang.org/genproto/googleapis/type/money"
+)
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		a, b *money.Money
+		want string
+	}{
+		{New("USD", 0, 100000000), New("USD", 0, 200000000), "0.30 USD"},
+		{New("USD", 1, 750000000), New("USD", 2, 500000000), "4.25 USD"},
+		{New("USD", -1, -750000000), New("USD", 0, 500000000), "-1.25 USD"},
+		{New("USD", 1, 0), New("USD", -1, -500000000), "-0.50 USD"},
+		{New("EUR", 10, 0), New("EUR", 0, 1), "10.000000001 EUR"},
+	}
+	for _, tt := range tests {
+		sum, err := Add(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("Add(%s, %s) error: %v", Format(tt.a), Format(tt.b), err)
+			continue
+		}
+		if got := Format(sum); got != tt.want {
+			t.Errorf("Add(%s, %s) = %s, want %s", Format(tt.a), Format(tt.b), got, tt.want)
+		}
+		if description := Check(sum); description != "" {
+			t.Errorf("Add(%s, %s) = %v is malformed: %s", Format(tt.a), Format(tt.b), sum, description)
+		}
+	}
+
+	if _, err := Add(New("USD", 1, 0), New("EUR", 1, 0)); err == nil {
+		t.Errorf("Add() in different currencies got no error")
+	}
+	if _, err := Add(New("USD", 1<<62, 0), New("USD", 1<<62, 0)); err == nil {
+		t.Errorf("Add() overflowing got no error")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	amounts := []string{"-1.50 USD", "-0.50 USD", "0.00 USD", "0.50 USD", "1.00 USD", "1.50 USD"}
+	values := []*money.Money{
+		New("USD", -1, -500000000),
+		New("USD", 0, -500000000),
+		New("USD", 0, 0),
+		New("USD", 0, 500000000),
+		New("USD", 1, 0),
+		New("USD", 1, 500000000),
+	}
+	for i := range values {
+		for j := range values {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := Compare(values[i], values[j]); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", amounts[i], amounts[j], got, want)
+			}
+		}
+	}
+}
+
+func TestAddToTotals(t *testing.T) {
+	totals, err := AddToTotals(nil, New("USD", 1, 0))
+	if err == nil {
+		totals, err = AddToTotals(totals, New("EUR", 2, 0))
+	}
+	if err == nil {
+		totals, err = AddToTotals(totals, New("USD", 0, 500000000))
+	}
+	if err != nil {
+		t.Fatalf("AddToTotals() error: %v", err)
+	}
+	if len(totals) != 2 || Format(totals[0]) != "1.50 USD" || Format(totals[1]) != "2.00 EUR" {
+		t.Errorf("AddToTotals() = %v, want [1.50 USD 2.00 EUR]", totals)
+	}
+	if _, err := AddToTotals(totals, nil); err == nil {
+		t.Errorf("AddToTotals() of no amount got no error")
+	}
+}
@@ -0,0 +1,277 @@
+// Package calllog writes one structured log entry per gRPC call, in JSON or logfmt,
+// with the payloads of the messages at the debug level.
+package calllog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIdKey is the metadata key of the request ID, the server sends it back in the header.
+const RequestIdKey = "x-request-id"
+
+// Level is the severity level of the call logs.
+type Level int
+
+const (
+	LevelDebug Level = iota // The payloads of the messages.
+	LevelInfo               // The successful calls.
+	LevelWarn               // The calls failed by the client, e.g. InvalidArgument or NotFound.
+	LevelError              // The calls failed by the server, e.g. Internal or Unavailable.
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return strconv.Itoa(int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses the name of the log level, e.g. "info".
+func ParseLevel(name string) (Level, error) {
+	for i, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, must be one of %s", name, strings.Join(levelNames, ", "))
+}
+
+// Get the level of the call log by the status code of the call.
+func levelForCode(code codes.Code) Level {
+	switch code {
+	case codes.OK:
+		return LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unauthenticated, codes.DeadlineExceeded:
+		return LevelWarn
+	}
+	return LevelError
+}
+
+// Config controls what the logger writes.
+type Config struct {
+	Format   string             // The output format: "json" or "logfmt".
+	Level    Level              // The min level of the logs to write.
+	Sampling map[string]float64 // The fraction of the successful calls to log, by the full or the short method name (case-insensitive). 1 for the unlisted methods.
+	Redact   []string           // The names of the payload fields to redact at any depth, e.g. "destination".
+}
+
+// ParseSampling parses the sampling rules in the form of "method=rate,...", e.g. "getOrder=0.1,/ecommerce.OrderManagement/searchOrders=0".
+func ParseSampling(rules string) (map[string]float64, error) {
+	sampling := make(map[string]float64)
+	for _, rule := range strings.Split(rules, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid sampling rule %q, must be method=rate", rule)
+		}
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid sampling rate %q, must be between 0 and 1", parts[1])
+		}
+		sampling[strings.TrimSpace(parts[0])] = rate
+	}
+	return sampling, nil
+}
+
+// Field is a key-value pair of a log entry, the fields are written in the order they are given.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger writes one structured log entry per call (request ID, method, status code and duration),
+// and the payloads of the messages at the debug level.
+// The failed calls are always logged, the successful calls are sampled per method.
+// The interceptors of the servers and the clients are built on it.
+type Logger struct {
+	Now    func() time.Time
+	Random func() float64 // Returns a number in [0, 1) for sampling.
+
+	mu     sync.Mutex
+	out    io.Writer
+	config Config
+	redact map[string]bool
+}
+
+// New creates a logger writing to out.
+func New(out io.Writer, config Config) (*Logger, error) {
+	if config.Format != "json" && config.Format != "logfmt" {
+		return nil, fmt.Errorf("unknown log format %q, must be json or logfmt", config.Format)
+	}
+	redact := make(map[string]bool)
+	for _, name := range config.Redact {
+		redact[name] = true
+	}
+	return &Logger{Now: time.Now, Random: mathrand.Float64, out: out, config: config, redact: redact}, nil
+}
+
+// Sampled decides whether the successful call of the method is logged.
+// The method is the full name sent by the client, e.g. "/ecommerce.OrderManagement/getOrder",
+// and the rate is looked up by the full name, then by the method name alone.
+func (l *Logger) Sampled(method string) bool {
+	rate, ok := l.config.Sampling[method]
+	if !ok {
+		rate, ok = l.config.Sampling[method[strings.LastIndex(method, "/")+1:]]
+	}
+	if !ok || rate >= 1 {
+		return true
+	}
+	return l.Random() < rate
+}
+
+// Log writes a log entry at the level, if the level is enabled.
+func (l *Logger) Log(level Level, msg string, fields ...Field) {
+	if level < l.config.Level {
+		return
+	}
+	fields = append([]Field{
+		{"time", l.Now().UTC().Format(time.RFC3339Nano)},
+		{"level", level.String()},
+		{"msg", msg},
+	}, fields...)
+
+	var buf bytes.Buffer
+	if l.config.Format == "json" {
+		buf.WriteByte('{')
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.Key)
+			value, err := json.Marshal(f.Value)
+			if err != nil {
+				value, _ = json.Marshal(fmt.Sprint(f.Value))
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	} else {
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(f.Key)
+			buf.WriteByte('=')
+			buf.WriteString(logfmtValue(f.Value))
+		}
+	}
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(buf.Bytes())
+}
+
+// Format the value for logfmt, the value with spaces, quotes or '=' is quoted.
+func logfmtValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		s = string(b)
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// LogMessage logs the payload of a message of the call at the debug level, e.g. "request received".
+func (l *Logger) LogMessage(msg, requestId, method string, m interface{}) {
+	if LevelDebug < l.config.Level {
+		return
+	}
+	l.Log(LevelDebug, msg, Field{"request_id", requestId}, Field{"method", method}, Field{"payload", l.Payload(m)})
+}
+
+// Payload converts the message into a JSON object for logging, with the redacted fields replaced.
+func (l *Logger) Payload(m interface{}) interface{} {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Sprintf("%T", m)
+	}
+	s, err := (&jsonpb.Marshaler{}).MarshalToString(msg)
+	if err != nil {
+		return fmt.Sprintf("%T", m)
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	return l.redactValue(v)
+}
+
+// Replace the values of the redacted fields at any depth.
+func (l *Logger) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if l.redact[key] {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = l.redactValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = l.redactValue(value)
+		}
+	}
+	return v
+}
+
+// Finish logs the end of the call, the successful call is only logged if it is sampled.
+func (l *Logger) Finish(requestId, method string, start time.Time, sampled bool, err error, fields ...Field) {
+	code := status.Code(err)
+	level := levelForCode(code)
+	if level == LevelInfo && !sampled {
+		return
+	}
+	duration := l.Now().Sub(start)
+	fields = append([]Field{
+		{"request_id", requestId},
+		{"method", method},
+		{"code", code.String()},
+		{"duration_ms", math.Round(float64(duration)/float64(time.Microsecond)) / 1000},
+	}, fields...)
+	if err != nil {
+		fields = append(fields, Field{"error", status.Convert(err).Message()})
+	}
+	l.Log(level, "call finished", fields...)
+}
+
+// NewRequestId generates a random request ID, e.g. "9f86d081884c7d65".
+func NewRequestId() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,66 @@
+package calllog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// Create a logger with a fixed clock.
+func newTestLogger(t *testing.T, out *bytes.Buffer, config Config) *Logger {
+	l, err := New(out, config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	l.Now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return l
+}
+
+func TestLogger_Formats(t *testing.T) {
+	var out bytes.Buffer
+	l := newTestLogger(t, &out, Config{Format: "logfmt", Level: LevelInfo})
+	l.Log(LevelDebug, "hidden")
+	l.Log(LevelInfo, "call finished", Field{"method", "/ecommerce.OrderManagement/getOrder"}, Field{"error", "Order does not exist"})
+	want := `time=2020-01-02T03:04:05Z level=info msg="call finished" method=/ecommerce.OrderManagement/getOrder error="Order does not exist"` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("logfmt output = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	l = newTestLogger(t, &out, Config{Format: "json", Level: LevelDebug, Redact: []string{"destination"}})
+	order := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"id":          {Kind: &structpb.Value_StringValue{StringValue: "101"}},
+		"destination": {Kind: &structpb.Value_StringValue{StringValue: "San Jose, CA"}},
+	}}
+	l.LogMessage("request received", "req-1", "/ecommerce.OrderManagement/addOrder", order)
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("json output %q error: %v", out.String(), err)
+	}
+	payload, _ := entry["payload"].(map[string]interface{})
+	if entry["level"] != "debug" || entry["request_id"] != "req-1" || payload["id"] != "101" || payload["destination"] != "[REDACTED]" {
+		t.Errorf("json output = %v, want debug level with id 101 and redacted destination", entry)
+	}
+
+	if _, err := New(&out, Config{Format: "xml"}); err == nil {
+		t.Errorf("New() with unknown format got no error")
+	}
+}
+
+func TestParseSampling(t *testing.T) {
+	sampling, err := ParseSampling("getOrder=0.1, /ecommerce.OrderManagement/searchOrders=0")
+	if err != nil {
+		t.Fatalf("ParseSampling() error: %v", err)
+	}
+	if sampling["getOrder"] != 0.1 || sampling["/ecommerce.OrderManagement/searchOrders"] != 0 || len(sampling) != 2 {
+		t.Errorf("ParseSampling() = %v", sampling)
+	}
+	for _, rules := range []string{"getOrder", "getOrder=2", "getOrder=x"} {
+		if _, err := ParseSampling(rules); err == nil {
+			t.Errorf("ParseSampling(%q) got no error", rules)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+module grpc-up-and-running/common
+
+require (
+	github.com/golang/protobuf v1.3.3
+	golang.org/x/net v0.0.0-20200114155413-6afb5195e5aa // indirect
+	golang.org/x/sys v0.0.0-20200124204421-9fbb57f87de9 // indirect
+	golang.org/x/text v0.3.2 // indirect
+	google.golang.org/genproto v0.0.0-20200128133413-58ce757ed39b
+	google.golang.org/grpc v1.27.0
+)
+
+go 1.13
@@ -0,0 +1,136 @@
+// Package idempotency remembers the results of the calls by the idempotency keys chosen by the clients,
+// so the retried calls are not executed twice.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultTTL is how long the results are remembered by default.
+	DefaultTTL = 10 * time.Minute
+
+	// KeyHeader is the metadata key of the idempotency key chosen by the client.
+	// The retries of a call must carry the same idempotency key as the original call.
+	KeyHeader = "idempotency-key"
+)
+
+// Cache remembers the results of the successful calls by their idempotency keys for a while,
+// so a retried call gets the original result instead of being executed again.
+// The failed calls are not remembered, their retries are executed again.
+type Cache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*callResult
+	nextSweep time.Time
+}
+
+// callResult is the result of a call with an idempotency key.
+type callResult struct {
+	fingerprint []byte        // The hash of the request, for detecting a key reused by a different request.
+	done        chan struct{} // Closed when the call is finished.
+	res         proto.Message // The response, only set when the call succeeds.
+	err         error         // The error, only set when the call fails.
+	expires     time.Time     // Zero until the call is finished.
+}
+
+// NewCache creates a cache which remembers the results for ttl.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, now: time.Now, entries: make(map[string]*callResult)}
+}
+
+// Do runs fn for the request, unless the same request with the same idempotency key has been run by the method.
+// The idempotency key is read from the incoming metadata, fn is always run if there is no key.
+// A concurrent retry waits for the original call to finish and gets the same result.
+// Returns an InvalidArgument error if the key has been used by a different request.
+func (c *Cache) Do(ctx context.Context, method string, req proto.Message, fn func() (proto.Message, error)) (proto.Message, error) {
+	key := idempotencyKey(ctx)
+	if key == "" {
+		return fn()
+	}
+	key = method + "/" + key
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to marshal request: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	fingerprint := sum[:]
+
+	now := c.now()
+	c.mu.Lock()
+	c.sweep(now)
+	entry, exists := c.entries[key]
+	if exists && !entry.expires.IsZero() && now.After(entry.expires) {
+		delete(c.entries, key)
+		exists = false
+	}
+	if !exists {
+		entry = &callResult{fingerprint: fingerprint, done: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	if exists {
+		if !bytes.Equal(entry.fingerprint, fingerprint) {
+			return nil, status.Errorf(codes.InvalidArgument, "Idempotency key %q has been used by a different request", idempotencyKey(ctx))
+		}
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return proto.Clone(entry.res), nil
+	}
+
+	res, err := fn()
+	c.mu.Lock()
+	if err != nil {
+		entry.err = err
+		delete(c.entries, key)
+	} else {
+		entry.res = proto.Clone(res)
+		entry.expires = c.now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+	close(entry.done)
+	return res, err
+}
+
+// Remove the expired entries, at most once per ttl, the caller must hold the lock.
+func (c *Cache) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+	for key, entry := range c.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.nextSweep = now.Add(c.ttl)
+}
+
+// Get the idempotency key from the incoming metadata.
+func idempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(KeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
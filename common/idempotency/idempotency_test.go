@@ -0,0 +1,58 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCache_Expire(t *testing.T) {
+	cache := NewCache(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(KeyHeader, "key"))
+	req := &wrapper.StringValue{Value: "request"}
+	calls := 0
+
+	run := func() {
+		if _, err := cache.Do(ctx, "Method", req, func() (proto.Message, error) {
+			calls++
+			return &wrapper.StringValue{Value: "response"}, nil
+		}); err != nil {
+			t.Fatalf("Do() error: %v", err)
+		}
+	}
+	run()
+	run()
+	if calls != 1 {
+		t.Errorf("calls = %d before the entry expires, want 1", calls)
+	}
+	now = now.Add(time.Minute + time.Second)
+	run()
+	if calls != 2 {
+		t.Errorf("calls = %d after the entry expires, want 2", calls)
+	}
+}
+
+func TestCache_ReusedKey(t *testing.T) {
+	cache := NewCache(time.Minute)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(KeyHeader, "key"))
+	fn := func() (proto.Message, error) { return &wrapper.StringValue{Value: "response"}, nil }
+
+	if _, err := cache.Do(ctx, "Method", &wrapper.StringValue{Value: "request"}, fn); err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if _, err := cache.Do(ctx, "Method", &wrapper.StringValue{Value: "other"}, fn); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Do() with a reused key got %v, want InvalidArgument", err)
+	}
+	// The keys are scoped by the method.
+	if _, err := cache.Do(ctx, "Other", &wrapper.StringValue{Value: "other"}, fn); err != nil {
+		t.Errorf("Do() of another method error: %v", err)
+	}
+}
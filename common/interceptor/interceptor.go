@@ -0,0 +1,55 @@
+// Package interceptor chains the server interceptors and has the helpers shared by the interceptors of the common module.
+// The server of this gRPC version only accepts one unary and one stream interceptor, so the interceptors are combined here.
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ChainUnaryServer chains the unary interceptors into one, the first interceptor is the outermost one.
+func ChainUnaryServer(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// ChainStreamServer chains the stream interceptors into one, the first interceptor is the outermost one.
+func ChainStreamServer(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, inner)
+			}
+		}
+		return next(srv, ss)
+	}
+}
+
+// FullMethod gets the full method name of the call sent by the client, e.g. "/ecommerce.OrderManagement/getOrder".
+// The generated unary handlers report the method names capitalized in the server info (e.g. "GetOrder"),
+// so the name is taken from the stream of the call, and infoMethod is only used if the context has no stream.
+func FullMethod(ctx context.Context, infoMethod string) string {
+	if method, ok := grpc.Method(ctx); ok {
+		return method
+	}
+	return infoMethod
+}
+
+// PanicError gets the error a panic of the handler is recorded with by the interceptors outside the recovery interceptor,
+// the panic is Internal as the recovery interceptor returns it.
+func PanicError(r interface{}) error {
+	return status.Errorf(codes.Internal, "panic: %v", r)
+}
@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestChainUnaryServer(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+	chain := ChainUnaryServer(interceptor("outer"), interceptor("inner"))
+	res, err := chain(context.Background(), "req", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls = append(calls, "handler")
+		return req, nil
+	})
+	if err != nil || res != "req" {
+		t.Fatalf("chain() = %v, %v, want req, nil", res, err)
+	}
+	if got := fmt.Sprint(calls); got != "[outer inner handler]" {
+		t.Errorf("chain() calls = %s, want [outer inner handler]", got)
+	}
+}
+
+func TestChainStreamServer(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.StreamServerInterceptor {
+		return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			calls = append(calls, name)
+			return handler(srv, ss)
+		}
+	}
+	chain := ChainStreamServer(interceptor("outer"), interceptor("inner"))
+	err := chain(nil, nil, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chain() error: %v", err)
+	}
+	if got := fmt.Sprint(calls); got != "[outer inner handler]" {
+		t.Errorf("chain() calls = %s, want [outer inner handler]", got)
+	}
+}
+
+// methodStream is the server transport stream of a call to the method.
+type methodStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s methodStream) Method() string {
+	return s.method
+}
+
+func TestFullMethod(t *testing.T) {
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), methodStream{method: "/ecommerce.OrderManagement/getOrder"})
+	if got := FullMethod(ctx, "/ecommerce.OrderManagement/GetOrder"); got != "/ecommerce.OrderManagement/getOrder" {
+		t.Errorf("FullMethod() = %s, want the method sent by the client", got)
+	}
+	if got := FullMethod(context.Background(), "/ecommerce.OrderManagement/GetOrder"); got != "/ecommerce.OrderManagement/GetOrder" {
+		t.Errorf("FullMethod() without stream = %s, want the method of the server info", got)
+	}
+}
@@ -0,0 +1,287 @@
+// Package metrics collects the Prometheus-style metrics of the gRPC calls on a server,
+// and serves them in the text exposition format over HTTP.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/interceptor"
+)
+
+// The upper bounds (in seconds) of the buckets of the latency histograms.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// methodLabels identifies the method of the calls in the metrics.
+type methodLabels struct {
+	rpcType string // unary, client_stream, server_stream or bidi_stream.
+	service string
+	method  string
+}
+
+// Split the full method name, e.g. "/ecommerce.OrderManagement/getOrder", into the labels.
+func newMethodLabels(rpcType, fullMethod string) methodLabels {
+	service, method := "unknown", fullMethod
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		service, method = strings.TrimPrefix(fullMethod[:i], "/"), fullMethod[i+1:]
+	}
+	return methodLabels{rpcType: rpcType, service: service, method: method}
+}
+
+// StreamType gets the type of the streaming call: client_stream, server_stream or bidi_stream.
+func StreamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	}
+	return "server_stream"
+}
+
+// histogram counts the observed values by the latency buckets.
+type histogram struct {
+	counts []uint64 // The counts of the values in each bucket, not cumulative.
+	sum    float64
+	count  uint64
+}
+
+// methodMetrics holds the metrics of one method.
+type methodMetrics struct {
+	started     uint64
+	handled     map[string]uint64 // By the status code.
+	latency     histogram
+	inFlight    int64 // The streams which are still running.
+	msgReceived uint64
+	msgSent     uint64
+	panics      uint64 // The panics recovered by the recovery interceptors.
+}
+
+// ServerMetrics collects the metrics of the calls on the server by the full method names sent by the clients,
+// and serves them in the text exposition format over HTTP.
+type ServerMetrics struct {
+	mu      sync.Mutex
+	methods map[methodLabels]*methodMetrics
+}
+
+// NewServerMetrics creates an empty set of server metrics.
+func NewServerMetrics() *ServerMetrics {
+	return &ServerMetrics{methods: make(map[methodLabels]*methodMetrics)}
+}
+
+// Update the metrics of the method by fn under the lock.
+func (m *ServerMetrics) update(labels methodLabels, fn func(mm *methodMetrics)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mm, ok := m.methods[labels]
+	if !ok {
+		mm = &methodMetrics{handled: make(map[string]uint64), latency: histogram{counts: make([]uint64, len(latencyBuckets))}}
+		m.methods[labels] = mm
+	}
+	fn(mm)
+}
+
+// Record the start of a call.
+func (m *ServerMetrics) start(labels methodLabels, stream bool) {
+	m.update(labels, func(mm *methodMetrics) {
+		mm.started++
+		if stream {
+			mm.inFlight++
+		}
+	})
+}
+
+// Record the end of a call with its status code and latency.
+func (m *ServerMetrics) finish(labels methodLabels, stream bool, err error, elapsed time.Duration) {
+	m.update(labels, func(mm *methodMetrics) {
+		if stream {
+			mm.inFlight--
+		}
+		mm.handled[status.Code(err).String()]++
+		seconds := elapsed.Seconds()
+		for i, bound := range latencyBuckets {
+			if seconds <= bound {
+				mm.latency.counts[i]++
+				break
+			}
+		}
+		mm.latency.sum += seconds
+		mm.latency.count++
+	})
+}
+
+// Panicked records a panic of a call recovered by the recovery interceptors,
+// rpcType is unary or the StreamType of the call.
+func (m *ServerMetrics) Panicked(rpcType, fullMethod string) {
+	m.update(newMethodLabels(rpcType, fullMethod), func(mm *methodMetrics) { mm.panics++ })
+}
+
+// Get the full method name of the call sent by the client, e.g. "/ecommerce.OrderManagement/getOrder".
+// UnaryInterceptor records the calls.
+// The call is recorded even if the handler panics, so the interceptor works on either side of the recovery interceptor.
+func (m *ServerMetrics) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	labels := newMethodLabels("unary", interceptor.FullMethod(ctx, info.FullMethod))
+	start := time.Now()
+	m.start(labels, false)
+	m.update(labels, func(mm *methodMetrics) { mm.msgReceived++ })
+	defer func() {
+		if r := recover(); r != nil {
+			m.finish(labels, false, interceptor.PanicError(r), time.Since(start))
+			panic(r)
+		}
+		if err == nil {
+			m.update(labels, func(mm *methodMetrics) { mm.msgSent++ })
+		}
+		m.finish(labels, false, err, time.Since(start))
+	}()
+	return handler(ctx, req)
+}
+
+// StreamInterceptor records the calls, the streams in flight and the messages received and sent.
+// The stream is recorded as ended even if the handler panics, so the streams in flight don't leak.
+func (m *ServerMetrics) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	labels := newMethodLabels(StreamType(info), interceptor.FullMethod(ss.Context(), info.FullMethod))
+	start := time.Now()
+	m.start(labels, true)
+	defer func() {
+		if r := recover(); r != nil {
+			m.finish(labels, true, interceptor.PanicError(r), time.Since(start))
+			panic(r)
+		}
+		m.finish(labels, true, err, time.Since(start))
+	}()
+	return handler(srv, &metricsServerStream{ss, m, labels})
+}
+
+// metricsServerStream counts the messages received and sent by the stream.
+type metricsServerStream struct {
+	grpc.ServerStream
+	metrics *ServerMetrics
+	labels  methodLabels
+}
+
+func (s *metricsServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.metrics.update(s.labels, func(mm *methodMetrics) { mm.msgReceived++ })
+	}
+	return err
+}
+
+func (s *metricsServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.metrics.update(s.labels, func(mm *methodMetrics) { mm.msgSent++ })
+	}
+	return err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *ServerMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteText(w)
+}
+
+// WriteText writes all the metrics in the text exposition format, sorted by the labels.
+func (m *ServerMetrics) WriteText(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	labels := make([]methodLabels, 0, len(m.methods))
+	for l := range m.methods {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if a.service != b.service {
+			return a.service < b.service
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.rpcType < b.rpcType
+	})
+
+	writeHeader := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	writeHeader("grpc_server_started_total", "counter", "Total number of RPCs started on the server.")
+	for _, l := range labels {
+		fmt.Fprintf(w, "grpc_server_started_total{%s} %d\n", l, m.methods[l].started)
+	}
+
+	writeHeader("grpc_server_handled_total", "counter", "Total number of RPCs completed on the server, regardless of success or failure.")
+	for _, l := range labels {
+		handled := m.methods[l].handled
+		codes := make([]string, 0, len(handled))
+		for code := range handled {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(w, "grpc_server_handled_total{%s,grpc_code=%q} %d\n", l, code, handled[code])
+		}
+	}
+
+	writeHeader("grpc_server_handling_seconds", "histogram", "Histogram of response latency (seconds) of the RPCs handled by the server.")
+	for _, l := range labels {
+		h := m.methods[l].latency
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "grpc_server_handling_seconds_bucket{%s,le=%q} %d\n", l, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "grpc_server_handling_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, h.count)
+		fmt.Fprintf(w, "grpc_server_handling_seconds_sum{%s} %s\n", l, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "grpc_server_handling_seconds_count{%s} %d\n", l, h.count)
+	}
+
+	writeHeader("grpc_server_streams_in_flight", "gauge", "Number of streaming RPCs currently running on the server.")
+	for _, l := range labels {
+		if l.rpcType != "unary" {
+			fmt.Fprintf(w, "grpc_server_streams_in_flight{%s} %d\n", l, m.methods[l].inFlight)
+		}
+	}
+
+	writeHeader("grpc_server_msg_received_total", "counter", "Total number of messages received by the server.")
+	for _, l := range labels {
+		fmt.Fprintf(w, "grpc_server_msg_received_total{%s} %d\n", l, m.methods[l].msgReceived)
+	}
+
+	writeHeader("grpc_server_msg_sent_total", "counter", "Total number of messages sent by the server.")
+	for _, l := range labels {
+		fmt.Fprintf(w, "grpc_server_msg_sent_total{%s} %d\n", l, m.methods[l].msgSent)
+	}
+
+	writeHeader("grpc_server_panics_recovered_total", "counter", "Total number of panics recovered in the RPCs handled by the server.")
+	for _, l := range labels {
+		fmt.Fprintf(w, "grpc_server_panics_recovered_total{%s} %d\n", l, m.methods[l].panics)
+	}
+}
+
+// Format the labels in the text exposition format.
+func (l methodLabels) String() string {
+	return fmt.Sprintf("grpc_type=%q,grpc_service=%q,grpc_method=%q", l.rpcType, l.service, l.method)
+}
+
+// Serve serves the metrics on the /metrics endpoint of a separate HTTP server in the background.
+func Serve(addr string, metrics *ServerMetrics) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	go func() {
+		log.Printf("Serving metrics on %s/metrics", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("failed to serve metrics: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewMethodLabels(t *testing.T) {
+	l := newMethodLabels("unary", "/ecommerce.OrderManagement/getOrder")
+	if want := `grpc_type="unary",grpc_service="ecommerce.OrderManagement",grpc_method="getOrder"`; l.String() != want {
+		t.Errorf("labels = %s, want %s", l, want)
+	}
+}
+
+func TestServerMetrics_Panicked(t *testing.T) {
+	m := NewServerMetrics()
+	m.Panicked("unary", "/ecommerce.OrderManagement/getOrder")
+	m.Panicked("unary", "/ecommerce.OrderManagement/getOrder")
+	m.Panicked("server_stream", "/ecommerce.OrderManagement/searchOrders")
+
+	var body strings.Builder
+	m.WriteText(&body)
+	for _, want := range []string{
+		"# TYPE grpc_server_panics_recovered_total counter\n",
+		`grpc_server_panics_recovered_total{grpc_type="unary",grpc_service="ecommerce.OrderManagement",grpc_method="getOrder"} 2` + "\n",
+		`grpc_server_panics_recovered_total{grpc_type="server_stream",grpc_service="ecommerce.OrderManagement",grpc_method="searchOrders"} 1` + "\n",
+	} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("metrics don't contain %q:\n%s", want, body.String())
+		}
+	}
+}
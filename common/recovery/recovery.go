@@ -0,0 +1,60 @@
+// Package recovery converts the panics of the gRPC calls on a server into Internal errors, so a panic doesn't crash the server.
+package recovery
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/calllog"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/metrics"
+)
+
+// PanicRecovery holds the interceptors recovering the panics of the calls.
+// The error only carries a correlation ID, the panic and its stack trace are logged under the same ID.
+// The panics in the goroutines started by the remote methods can't be recovered here.
+type PanicRecovery struct {
+	logger  *log.Logger
+	metrics *metrics.ServerMetrics // The panics are counted in the metrics if not nil.
+}
+
+// New creates the panic recovery logging to logger, and counting the panics in serverMetrics if it is not nil.
+func New(logger *log.Logger, serverMetrics *metrics.ServerMetrics) *PanicRecovery {
+	return &PanicRecovery{logger: logger, metrics: serverMetrics}
+}
+
+// Log and count the recovered panic, and create the error returned to the client.
+// The full method name is the one sent by the client, as in the other metrics of the call.
+func (p *PanicRecovery) recovered(ctx context.Context, rpcType, infoMethod string, r interface{}) error {
+	fullMethod := interceptor.FullMethod(ctx, infoMethod)
+	correlationId := calllog.NewRequestId()
+	p.logger.Printf("panic recovered in %s, correlation ID %s: %v\n%s", fullMethod, correlationId, r, debug.Stack())
+	if p.metrics != nil {
+		p.metrics.Panicked(rpcType, fullMethod)
+	}
+	return status.Errorf(codes.Internal, "internal error, correlation ID %s", correlationId)
+}
+
+// UnaryInterceptor recovers the panics of the calls.
+func (p *PanicRecovery) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, p.recovered(ctx, "unary", info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// StreamInterceptor recovers the panics of the calls.
+func (p *PanicRecovery) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = p.recovered(ss.Context(), metrics.StreamType(info), info.FullMethod, r)
+		}
+	}()
+	return handler(srv, ss)
+}
@@ -0,0 +1,48 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/metrics"
+)
+
+func TestPanicRecovery_UnaryInterceptor(t *testing.T) {
+	var out bytes.Buffer
+	serverMetrics := metrics.NewServerMetrics()
+	p := New(log.New(&out, "", 0), serverMetrics)
+	info := &grpc.UnaryServerInfo{FullMethod: "/ecommerce.ProductInfo/addProduct"}
+
+	_, err := p.UnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("implement me")
+	})
+	st := status.Convert(err)
+	const prefix = "internal error, correlation ID "
+	if st.Code() != codes.Internal || !strings.HasPrefix(st.Message(), prefix) {
+		t.Fatalf("UnaryInterceptor() error = %v, want Internal with the correlation ID", err)
+	}
+	id := strings.TrimPrefix(st.Message(), prefix)
+	if want := "panic recovered in /ecommerce.ProductInfo/addProduct, correlation ID " + id + ": implement me"; !strings.Contains(out.String(), want) {
+		t.Errorf("logs = %q, want %q", out.String(), want)
+	}
+
+	// The calls without a panic are left as they are.
+	res, err := p.UnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	if res != "ok" || err != nil {
+		t.Errorf("UnaryInterceptor() = %v, %v, want ok", res, err)
+	}
+
+	var body strings.Builder
+	serverMetrics.WriteText(&body)
+	if want := `grpc_server_panics_recovered_total{grpc_type="unary",grpc_service="ecommerce.ProductInfo",grpc_method="addProduct"} 1`; !strings.Contains(body.String(), want) {
+		t.Errorf("metrics don't contain %q:\n%s", want, body.String())
+	}
+}
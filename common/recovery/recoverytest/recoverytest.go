@@ -0,0 +1,43 @@
+// Package recoverytest has the helpers for testing the servers recovering the panics by the recovery package.
+package recoverytest
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SyncBuffer is a bytes.Buffer safe for concurrent use, e.g. the output of the logger of the recovery interceptor,
+// which is written by the server while the test reads it.
+type SyncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *SyncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *SyncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// CorrelationId gets the correlation ID in the message of the Internal error returned for a recovered panic,
+// the test fails if the error is not such an error.
+func CorrelationId(t testing.TB, err error) string {
+	t.Helper()
+	st := status.Convert(err)
+	const prefix = "internal error, correlation ID "
+	if st.Code() != codes.Internal || !strings.HasPrefix(st.Message(), prefix) {
+		t.Fatalf("got %v, want Internal with the correlation ID", err)
+	}
+	return strings.TrimPrefix(st.Message(), prefix)
+}
@@ -0,0 +1,321 @@
+// Package tracing records the spans of the gRPC calls and propagates the W3C trace context,
+// the finished spans are exported in the OTLP JSON format.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TraceparentKey is the metadata key of the W3C trace context.
+const TraceparentKey = "traceparent"
+
+// TraceContext identifies a span in a trace, it is propagated in the W3C traceparent format "00-<trace ID>-<span ID>-<flags>".
+type TraceContext struct {
+	TraceId [16]byte
+	SpanId  [8]byte
+	Sampled bool // Whether the spans of the trace are exported.
+}
+
+// The versions after 00 may append more fields, they are ignored.
+var traceparentPattern = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(-.*)?$`)
+
+// ParseTraceparent parses the traceparent, e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(s string) (TraceContext, error) {
+	var tc TraceContext
+	parts := traceparentPattern.FindStringSubmatch(s)
+	if parts == nil || parts[1] == "ff" || (parts[1] == "00" && parts[5] != "") {
+		return tc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	hex.Decode(tc.TraceId[:], []byte(parts[2]))
+	hex.Decode(tc.SpanId[:], []byte(parts[3]))
+	if tc.TraceId == [16]byte{} || tc.SpanId == [8]byte{} {
+		return tc, fmt.Errorf("invalid traceparent %q, the trace ID and the span ID must not be all zeros", s)
+	}
+	flags, _ := strconv.ParseUint(parts[4], 16, 8)
+	tc.Sampled = flags&1 == 1
+	return tc, nil
+}
+
+// Format the trace context as the traceparent.
+func (tc TraceContext) String() string {
+	flags := 0
+	if tc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%x-%x-%02x", tc.TraceId, tc.SpanId, flags)
+}
+
+type contextKey struct{}
+
+// FromContext gets the trace context of the current span from the context.
+func FromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(contextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// NewContext puts the trace context of the current span into the context, the spans started from the context are its children.
+func NewContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, tc)
+}
+
+// SpanKind is the kind of a span, the values are the ones of OTLP.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Attribute is a key-value pair of a span, the value is a string, an int64 or a bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span records one operation of a trace, e.g. a call or a stream message.
+type Span struct {
+	Name          string
+	Kind          SpanKind
+	Context       TraceContext
+	ParentSpanId  [8]byte // All zeros for the root span.
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	StatusCode    codes.Code
+	StatusMessage string
+
+	tracer *Tracer
+	once   sync.Once
+}
+
+// Finish ends the span with the status of err, the span is exported if its trace is sampled.
+// Only the first call takes effect.
+func (s *Span) Finish(err error) {
+	s.once.Do(func() {
+		s.End = s.tracer.Now()
+		if err != nil {
+			st := status.Convert(err)
+			s.StatusCode, s.StatusMessage = st.Code(), st.Message()
+		}
+		if s.Context.Sampled && s.tracer.exporter != nil {
+			s.tracer.exporter.Export(s)
+		}
+	})
+}
+
+// SpanExporter sends the finished spans to a tracing backend.
+// The implementations must be safe for concurrent use.
+type SpanExporter interface {
+	// Export a finished span.
+	Export(s *Span) error
+	// Close the exporter.
+	Close() error
+}
+
+// MemorySpanExporter keeps the exported spans in memory, it is used by tests.
+type MemorySpanExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func NewMemorySpanExporter() *MemorySpanExporter {
+	return &MemorySpanExporter{}
+}
+
+func (e *MemorySpanExporter) Export(s *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, s)
+	return nil
+}
+
+func (e *MemorySpanExporter) Close() error {
+	return nil
+}
+
+// Spans gets the exported spans in the order they are finished.
+func (e *MemorySpanExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// FileSpanExporter appends the spans to a file in the OTLP JSON format,
+// one ExportTraceServiceRequest per line as the file exporter of the OpenTelemetry Collector writes.
+type FileSpanExporter struct {
+	mu          sync.Mutex
+	out         io.WriteCloser
+	serviceName string // The "service.name" attribute of the resource, and the name of the instrumentation scope.
+}
+
+func NewFileSpanExporter(path, serviceName string) (*FileSpanExporter, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSpanExporter{out: f, serviceName: serviceName}, nil
+}
+
+func (e *FileSpanExporter) Export(s *Span) error {
+	line, err := json.Marshal(otlpTraceRequest(e.serviceName, s))
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.out.Write(append(line, '\n'))
+	return err
+}
+
+func (e *FileSpanExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.out.Close()
+}
+
+// The OTLP JSON messages, see opentelemetry/proto/collector/trace/v1/trace_service.proto.
+// The IDs are hex strings and the 64-bit integers are decimal strings in the OTLP JSON encoding.
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 0 unset, 1 ok, 2 error. The successful spans are left unset as the OpenTelemetry conventions suggest.
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+// Convert the span into an ExportTraceServiceRequest, the spans are instrumented by the service itself.
+func otlpTraceRequest(serviceName string, s *Span) map[string]interface{} {
+	out := otlpSpan{
+		TraceId:           hex.EncodeToString(s.Context.TraceId[:]),
+		SpanId:            hex.EncodeToString(s.Context.SpanId[:]),
+		Name:              s.Name,
+		Kind:              s.Kind,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+	}
+	if s.ParentSpanId != [8]byte{} {
+		out.ParentSpanId = hex.EncodeToString(s.ParentSpanId[:])
+	}
+	for _, a := range s.Attributes {
+		out.Attributes = append(out.Attributes, otlpAttribute(a))
+	}
+	if s.StatusCode != codes.OK {
+		out.Status = otlpStatus{Code: 2, Message: s.StatusMessage}
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpKeyValue{otlpAttribute(Attribute{"service.name", serviceName})},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": serviceName},
+				"spans": []otlpSpan{out},
+			}},
+		}},
+	}
+}
+
+func otlpAttribute(a Attribute) otlpKeyValue {
+	switch v := a.Value.(type) {
+	case int64:
+		return otlpKeyValue{a.Key, map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}}
+	case bool:
+		return otlpKeyValue{a.Key, map[string]interface{}{"boolValue": v}}
+	}
+	return otlpKeyValue{a.Key, map[string]interface{}{"stringValue": fmt.Sprint(a.Value)}}
+}
+
+// Tracer creates the spans of the calls and exports them when they are finished.
+// The interceptors of the servers and the clients are built on it.
+type Tracer struct {
+	Now func() time.Time
+
+	exporter SpanExporter // The spans are discarded if nil.
+}
+
+// NewTracer creates a tracer exporting the spans to exporter, or discarding them if exporter is nil.
+func NewTracer(exporter SpanExporter) *Tracer {
+	return &Tracer{Now: time.Now, exporter: exporter}
+}
+
+// StartSpan starts a span as the child of the current span in the context, or as a root span of a new sampled trace.
+// The returned context carries the new span.
+func (t *Tracer) StartSpan(ctx context.Context, name string, kind SpanKind, attributes ...Attribute) (context.Context, *Span) {
+	s := &Span{Name: name, Kind: kind, Start: t.Now(), Attributes: attributes, tracer: t}
+	if parent, ok := FromContext(ctx); ok {
+		s.Context.TraceId, s.Context.Sampled = parent.TraceId, parent.Sampled
+		s.ParentSpanId = parent.SpanId
+	} else {
+		s.Context.TraceId, s.Context.Sampled = newTraceId(), true
+	}
+	s.Context.SpanId = newSpanId()
+	return NewContext(ctx, s.Context), s
+}
+
+// MessageSpan records a message of a stream as a child span of the call, started at start.
+func (t *Tracer) MessageSpan(ctx context.Context, name, messageType string, id int64, start time.Time, err error) {
+	_, s := t.StartSpan(ctx, name, SpanKindInternal, Attribute{"message.type", messageType}, Attribute{"message.id", id})
+	s.Start = start
+	s.Finish(err)
+}
+
+// Generate a random trace ID, never all zeros.
+func newTraceId() (id [16]byte) {
+	for id == [16]byte{} {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// Generate a random span ID, never all zeros.
+func newSpanId() (id [8]byte) {
+	for id == [8]byte{} {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// RpcSpan gets the name and the attributes of the span of the call by the full method name, e.g. "/ecommerce.OrderManagement/getOrder".
+func RpcSpan(fullMethod string) (string, []Attribute) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	service, method := "", name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		service, method = name[:i], name[i+1:]
+	}
+	return name, []Attribute{{"rpc.system", "grpc"}, {"rpc.service", service}, {"rpc.method", method}}
+}
+
+// FinishRpcSpan adds the gRPC status code of the call to the span and ends it.
+func FinishRpcSpan(s *Span, err error) {
+	s.Attributes = append(s.Attributes, Attribute{"rpc.grpc.status_code", int64(status.Code(err))})
+	s.Finish(err)
+}
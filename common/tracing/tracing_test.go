@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := ParseTraceparent(testTraceparent)
+	if err != nil {
+		t.Fatalf("ParseTraceparent() error: %v", err)
+	}
+	if !tc.Sampled || tc.String() != testTraceparent {
+		t.Errorf("ParseTraceparent() = %v, want %s sampled", tc, testTraceparent)
+	}
+	if tc, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil || tc.Sampled {
+		t.Errorf("ParseTraceparent() of a future version = %v, %v, want the unsampled trace context", tc, err)
+	}
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(s); err == nil {
+			t.Errorf("ParseTraceparent(%q) got no error", s)
+		}
+	}
+}
+
+func TestFileSpanExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.jsonl")
+	exporter, err := NewFileSpanExporter(path, "ordermgt-service")
+	if err != nil {
+		t.Fatalf("NewFileSpanExporter() error: %v", err)
+	}
+	tracer := NewTracer(exporter)
+	ctx := NewContext(context.Background(), mustParseTraceparent(t, testTraceparent))
+	name, attributes := RpcSpan("/ecommerce.OrderManagement/getOrder")
+	_, s := tracer.StartSpan(ctx, name, SpanKindServer, attributes...)
+	FinishRpcSpan(s, nil)
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	var request struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpKeyValue `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		t.Fatalf("trace file %q error: %v", data, err)
+	}
+	if len(request.ResourceSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatalf("trace file = %s, want one span", data)
+	}
+	if attrs := request.ResourceSpans[0].Resource.Attributes; len(attrs) != 1 || attrs[0].Value["stringValue"] != "ordermgt-service" {
+		t.Errorf("resource attributes = %v, want the service name", attrs)
+	}
+	if scope := request.ResourceSpans[0].ScopeSpans[0].Scope.Name; scope != "ordermgt-service" {
+		t.Errorf("scope name = %q, want the service name", scope)
+	}
+	got := request.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if got.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || got.ParentSpanId != "00f067aa0ba902b7" || got.Name != "ecommerce.OrderManagement/getOrder" || got.Kind != SpanKindServer {
+		t.Errorf("span = %+v", got)
+	}
+	if len(got.Attributes) != 4 || got.Attributes[3].Key != "rpc.grpc.status_code" || got.Attributes[3].Value["intValue"] != "0" {
+		t.Errorf("span attributes = %+v, want the rpc attributes with the status code", got.Attributes)
+	}
+}
+
+func mustParseTraceparent(t *testing.T, s string) TraceContext {
+	tc, err := ParseTraceparent(s)
+	if err != nil {
+		t.Fatalf("ParseTraceparent() error: %v", err)
+	}
+	return tc
+}
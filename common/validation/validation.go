@@ -0,0 +1,149 @@
+// Package validation checks the request messages by declarative rules,
+// and reports every violated field in one InvalidArgument error with the BadRequest details.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	money "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+)
+
+// Rules are the validation rules of the request messages, by the full name of the message.
+// All the rules of a message are checked, every violated field is reported in one BadRequest.
+type Rules map[string][]FieldRule
+
+// FieldRule checks one field of a message, returns the violations of the field.
+type FieldRule func(m proto.Message) []*epb.BadRequest_FieldViolation
+
+// StringCheck checks a string value, returns the description of the violation or "" if the value is valid.
+type StringCheck func(value string) string
+
+// MoneyCheck checks a well-formed amount of money, returns the description of the violation or "" if the value is valid.
+type MoneyCheck func(value *money.Money) string
+
+// StringField declares the checks on a string field.
+func StringField(name string, get func(m proto.Message) string, checks ...StringCheck) FieldRule {
+	return func(m proto.Message) []*epb.BadRequest_FieldViolation {
+		return checkString(name, get(m), checks)
+	}
+}
+
+// ListField declares the checks on a repeated string field, which must have at least minCount elements.
+// The checks are applied on each element, the violations are reported as "name[index]".
+func ListField(name string, get func(m proto.Message) []string, minCount int, checks ...StringCheck) FieldRule {
+	return func(m proto.Message) []*epb.BadRequest_FieldViolation {
+		values := get(m)
+		if len(values) < minCount {
+			return []*epb.BadRequest_FieldViolation{{
+				Field:       name,
+				Description: fmt.Sprintf("must have at least %d element(s)", minCount),
+			}}
+		}
+		var violations []*epb.BadRequest_FieldViolation
+		for i, value := range values {
+			violations = append(violations, checkString(fmt.Sprintf("%s[%d]", name, i), value, checks)...)
+		}
+		return violations
+	}
+}
+
+// MoneyField declares the checks on a money field, the field is required and must be well-formed before the checks are applied.
+func MoneyField(name string, get func(m proto.Message) *money.Money, checks ...MoneyCheck) FieldRule {
+	return func(m proto.Message) []*epb.BadRequest_FieldViolation {
+		value := get(m)
+		if value == nil {
+			return []*epb.BadRequest_FieldViolation{{Field: name, Description: "must not be empty"}}
+		}
+		if description := amount.Check(value); description != "" {
+			return []*epb.BadRequest_FieldViolation{{Field: name, Description: description}}
+		}
+		for _, check := range checks {
+			if description := check(value); description != "" {
+				return []*epb.BadRequest_FieldViolation{{Field: name, Description: description}}
+			}
+		}
+		return nil
+	}
+}
+
+// Apply the checks on the value until the first violation.
+func checkString(name string, value string, checks []StringCheck) []*epb.BadRequest_FieldViolation {
+	for _, check := range checks {
+		if description := check(value); description != "" {
+			return []*epb.BadRequest_FieldViolation{{Field: name, Description: description}}
+		}
+	}
+	return nil
+}
+
+// Required checks the value is not empty or blank.
+func Required() StringCheck {
+	return func(value string) string {
+		if strings.TrimSpace(value) == "" {
+			return "must not be empty"
+		}
+		return ""
+	}
+}
+
+// MaxLength checks the value has at most n characters.
+func MaxLength(n int) StringCheck {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > n {
+			return fmt.Sprintf("must have at most %d characters", n)
+		}
+		return ""
+	}
+}
+
+// Pattern checks the value matches the pattern, the description explains the pattern.
+// The empty value is skipped, use Required() to reject it.
+func Pattern(re *regexp.Regexp, description string) StringCheck {
+	return func(value string) string {
+		if value != "" && !re.MatchString(value) {
+			return description
+		}
+		return ""
+	}
+}
+
+// Positive checks the amount is greater than 0.
+func Positive() MoneyCheck {
+	return func(value *money.Money) string {
+		if value.Units < 0 || value.Units == 0 && value.Nanos <= 0 {
+			return "must be greater than 0"
+		}
+		return ""
+	}
+}
+
+// Validate validates the message by the rules of its type, the message without rules is always valid.
+// Returns an InvalidArgument error with the BadRequest details listing every violated field.
+func (r Rules) Validate(m interface{}) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil
+	}
+	var violations []*epb.BadRequest_FieldViolation
+	for _, rule := range r[proto.MessageName(msg)] {
+		violations = append(violations, rule(msg)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	errorStatus := status.New(codes.InvalidArgument, "Invalid information received")
+	ds, err := errorStatus.WithDetails(&epb.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return errorStatus.Err()
+	}
+	return ds.Err()
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	money "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+)
+
+// Get the violations from the BadRequest details of the error, as "field: description".
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+	var got []string
+	for _, d := range st.Details() {
+		if badRequest, ok := d.(*epb.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				got = append(got, violation.Field+": "+violation.Description)
+			}
+		}
+	}
+	return got
+}
+
+func TestRules_Validate(t *testing.T) {
+	rules := Rules{
+		proto.MessageName(&wrapper.StringValue{}): {
+			StringField("value", func(m proto.Message) string { return m.(*wrapper.StringValue).Value },
+				Required(), MaxLength(4), Pattern(regexp.MustCompile(`^[a-z]+$`), "must be lowercase")),
+		},
+		proto.MessageName(&money.Money{}): {
+			MoneyField("amount", func(m proto.Message) *money.Money { return m.(*money.Money) }, Positive()),
+		},
+	}
+	tests := []struct {
+		name string
+		m    interface{}
+		want []string
+	}{
+		{"valid", &wrapper.StringValue{Value: "abc"}, nil},
+		{"blank", &wrapper.StringValue{Value: " "}, []string{"value: must not be empty"}},
+		{"too long", &wrapper.StringValue{Value: "abcde"}, []string{"value: must have at most 4 characters"}},
+		{"pattern", &wrapper.StringValue{Value: "ABC"}, []string{"value: must be lowercase"}},
+		{"positive", amount.New("USD", 0, 0), []string{"amount: must be greater than 0"}},
+		{"malformed", amount.New("USD", 1, -1), []string{"amount: units and nanos must have the same sign"}},
+		{"no rules", &wrapper.Int64Value{}, nil},
+		{"not a message", "abc", nil},
+	}
+	for _, tt := range tests {
+		err := rules.Validate(tt.m)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: Validate() error: %v", tt.name, err)
+			}
+			continue
+		}
+		if got := violations(t, err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Validate() violations = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListField(t *testing.T) {
+	rule := ListField("items", func(m proto.Message) []string { return []string{"a", "", "b"} }, 1, Required())
+	got := rule(&wrapper.StringValue{})
+	if len(got) != 1 || got[0].Field != "items[1]" {
+		t.Errorf("ListField() violations = %v, want items[1]", got)
+	}
+	rule = ListField("items", func(m proto.Message) []string { return nil }, 1, Required())
+	if got := rule(&wrapper.StringValue{}); len(got) != 1 || got[0].Description != "must have at least 1 element(s)" {
+		t.Errorf("ListField() of no elements = %v, want too few elements", got)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"net"
 
 	"google.golang.org/grpc"
+	"grpc-up-and-running/common/metrics"
 	pb "examples/grpc-gateway/server/ecommerce"
 )
 
 const (
-	port = ":50051"
+	port        = ":50051"
+	metricsPort = ":9090"
 )
 
 func main() {
@@ -17,9 +19,15 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
+	serverMetrics := metrics.NewServerMetrics()
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(serverMetrics.UnaryInterceptor),
+		grpc.StreamInterceptor(serverMetrics.StreamInterceptor))
 	pb.RegisterProductInfoServer(s, &server{})
 
+	// Serve the metrics on a separate HTTP server.
+	metrics.Serve(metricsPort, serverMetrics)
+
 	log.Printf("Starting gRPC server on port " + port)
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
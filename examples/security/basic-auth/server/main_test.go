@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"grpc-up-and-running/common/metrics"
+	"grpc-up-and-running/common/recovery/recoverytest"
+	pb "grpc-up-and-running/examples/security/basic-auth/server/ecommerce"
+)
+
+// The remote methods not implemented yet panic, the panics are recovered and the server keeps serving the next calls.
+func TestServer_PanicRecovery(t *testing.T) {
+	var out recoverytest.SyncBuffer
+	serverMetrics := metrics.NewServerMetrics()
+	listener := bufconn.Listen(1024 * 1024)
+	s := newServer(serverMetrics, log.New(&out, "", 0))
+	go s.Serve(listener)
+	defer s.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) { return listener.Dial() }))
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewProductInfoClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+
+	_, err = client.AddProduct(ctx, &pb.Product{Name: "Apple iPhone 11"})
+	addId := recoverytest.CorrelationId(t, err)
+	if logs := out.String(); !strings.Contains(logs, "panic recovered in /ecommerce.ProductInfo/addProduct, correlation ID "+addId+": implement me") {
+		t.Errorf("logs = %q, want the panic of addProduct with the correlation ID %s", logs, addId)
+	}
+
+	// The second call on the same server panics too, under another correlation ID.
+	_, err = client.GetProduct(ctx, &pb.ProductID{Value: "1"})
+	if getId := recoverytest.CorrelationId(t, err); getId == addId {
+		t.Errorf("the correlation IDs of the 2 panics are both %s", addId)
+	}
+
+	var body strings.Builder
+	serverMetrics.WriteText(&body)
+	for _, want := range []string{
+		`grpc_server_panics_recovered_total{grpc_type="unary",grpc_service="ecommerce.ProductInfo",grpc_method="addProduct"} 1`,
+		`grpc_server_panics_recovered_total{grpc_type="unary",grpc_service="ecommerce.ProductInfo",grpc_method="getProduct"} 1`,
+		`grpc_server_handled_total{grpc_type="unary",grpc_service="ecommerce.ProductInfo",grpc_method="getProduct",grpc_code="Internal"} 1`,
+	} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("metrics don't contain %q:\n%s", want, body.String())
+		}
+	}
+}
@@ -8,6 +8,9 @@ require (
 	golang.org/x/text v0.3.2 // indirect
 	google.golang.org/genproto v0.0.0-20200128133413-58ce757ed39b // indirect
 	google.golang.org/grpc v1.27.0
+	grpc-up-and-running/common v0.0.0
 )
 
+replace grpc-up-and-running/common => ../../../../common
+
 go 1.13
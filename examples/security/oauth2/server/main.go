@@ -8,9 +8,13 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/metrics"
+	"grpc-up-and-running/common/recovery"
 	pb "grpc-up-and-running/examples/security/oauth2/server/ecommerce"
 	"log"
 	"net"
+	"os"
 	"strings"
 )
 
@@ -18,6 +22,7 @@ type server struct {}
 
 var (
 	port               = ":50051"
+	metricsPort        = ":9090"
 	crtFile            = "server.crt"    // server public certificate.
 	keyFile            = "server.key"    // server private key.
 
@@ -33,14 +38,12 @@ func main() {
 		log.Fatalf("failed to load key pair: %s", err)
 	}
 
-	opts := []grpc.ServerOption{
-		// Enable TLS for all incoming connections.
-		grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
-		grpc.UnaryInterceptor(ensureValidToken),
-	}
+	serverMetrics := metrics.NewServerMetrics()
+	// Enable TLS for all incoming connections.
+	s := newServer(serverMetrics, log.New(os.Stderr, "", log.LstdFlags), grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
 
-	s := grpc.NewServer(opts...)
-	pb.RegisterProductInfoServer(s, &server{})
+	// Serve the metrics on a separate HTTP server.
+	metrics.Serve(metricsPort, serverMetrics)
 
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
@@ -52,6 +55,19 @@ func main() {
 	}
 }
 
+// Create the gRPC server with the ProductInfo service registered, the calls are recorded in the metrics
+// and the panics of the remote methods (e.g. the ones not implemented yet) are recovered and logged to logger.
+func newServer(serverMetrics *metrics.ServerMetrics, logger *log.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	panicRecovery := recovery.New(logger, serverMetrics)
+	opts = append(opts,
+		grpc.UnaryInterceptor(interceptor.ChainUnaryServer(serverMetrics.UnaryInterceptor, panicRecovery.UnaryInterceptor, ensureValidToken)),
+		grpc.StreamInterceptor(interceptor.ChainStreamServer(serverMetrics.StreamInterceptor, panicRecovery.StreamInterceptor)),
+	)
+	s := grpc.NewServer(opts...)
+	pb.RegisterProductInfoServer(s, &server{})
+	return s
+}
+
 // This method ensures a valid token exists within a request's metadata.
 // - If the token is missing or invalid, the interceptor blocks execution of the handler and returns an error.
 // - Otherwise, the interceptor invokes the unary handler.
@@ -5,15 +5,20 @@ import (
 	"crypto/tls"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/metrics"
+	"grpc-up-and-running/common/recovery"
 	pb "grpc-up-and-running/examples/security/one-way-tls/server/ecommerce"
 	"log"
 	"net"
+	"os"
 )
 
 type server struct {}
 
 var (
 	port = ":50051"
+	metricsPort = ":9090"
 	crtFile = "server.crt"    // server public certificate.
 	keyFile = "server.key"    // server private key.
 )
@@ -23,12 +28,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to load key pair: %s", err)
 	}
-	opts := []grpc.ServerOption{
-		grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
-	}
+	serverMetrics := metrics.NewServerMetrics()
+	s := newServer(serverMetrics, log.New(os.Stderr, "", log.LstdFlags), grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
 
-	s := grpc.NewServer(opts...)
-	pb.RegisterProductInfoServer(s, &server{})
+	// Serve the metrics on a separate HTTP server.
+	metrics.Serve(metricsPort, serverMetrics)
 
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
@@ -40,6 +44,19 @@ func main() {
 	}
 }
 
+// Create the gRPC server with the ProductInfo service registered, the calls are recorded in the metrics
+// and the panics of the remote methods (e.g. the ones not implemented yet) are recovered and logged to logger.
+func newServer(serverMetrics *metrics.ServerMetrics, logger *log.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	panicRecovery := recovery.New(logger, serverMetrics)
+	opts = append(opts,
+		grpc.UnaryInterceptor(interceptor.ChainUnaryServer(serverMetrics.UnaryInterceptor, panicRecovery.UnaryInterceptor)),
+		grpc.StreamInterceptor(interceptor.ChainStreamServer(serverMetrics.StreamInterceptor, panicRecovery.StreamInterceptor)),
+	)
+	s := grpc.NewServer(opts...)
+	pb.RegisterProductInfoServer(s, &server{})
+	return s
+}
+
 func (s server) AddProduct(context.Context, *pb.Product) (*pb.ProductID, error) {
 	panic("implement me")
 }
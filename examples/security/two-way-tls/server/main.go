@@ -6,16 +6,21 @@ import (
 	"crypto/x509"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/metrics"
+	"grpc-up-and-running/common/recovery"
 	pb "grpc-up-and-running/examples/security/two-way-tls/server/ecommerce"
 	"io/ioutil"
 	"log"
 	"net"
+	"os"
 )
 
 type server struct {}
 
 var (
 	port = ":50051"
+	metricsPort = ":9090"
 	crtFile = "server.crt"    // server public certificate.
 	keyFile = "server.key"    // server private key.
 	caFile = "ca.crt"         // public certificate of a CA used to sign all public certificates.
@@ -36,18 +41,18 @@ func main() {
 		log.Fatalf("failed to append ca certificate")
 	}
 
-	opts := []grpc.ServerOption{
+	serverMetrics := metrics.NewServerMetrics()
+	s := newServer(serverMetrics, log.New(os.Stderr, "", log.LstdFlags),
 		grpc.Creds(
 			credentials.NewTLS(&tls.Config {
 				ClientAuth:   tls.RequireAndVerifyClientCert,
 				Certificates: []tls.Certificate{cert},
 				ClientCAs:    certPool,
 			},
-		)),
-	}
+		)))
 
-	s := grpc.NewServer(opts...)
-	pb.RegisterProductInfoServer(s, &server{})
+	// Serve the metrics on a separate HTTP server.
+	metrics.Serve(metricsPort, serverMetrics)
 
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
@@ -59,6 +64,19 @@ func main() {
 	}
 }
 
+// Create the gRPC server with the ProductInfo service registered, the calls are recorded in the metrics
+// and the panics of the remote methods (e.g. the ones not implemented yet) are recovered and logged to logger.
+func newServer(serverMetrics *metrics.ServerMetrics, logger *log.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	panicRecovery := recovery.New(logger, serverMetrics)
+	opts = append(opts,
+		grpc.UnaryInterceptor(interceptor.ChainUnaryServer(serverMetrics.UnaryInterceptor, panicRecovery.UnaryInterceptor)),
+		grpc.StreamInterceptor(interceptor.ChainStreamServer(serverMetrics.StreamInterceptor, panicRecovery.StreamInterceptor)),
+	)
+	s := grpc.NewServer(opts...)
+	pb.RegisterProductInfoServer(s, &server{})
+	return s
+}
+
 func (s server) AddProduct(context.Context, *pb.Product) (*pb.ProductID, error) {
 	panic("implement me")
 }
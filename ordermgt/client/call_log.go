@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"grpc-up-and-running/common/calllog"
+)
+
+// callLogger adds the client interceptors to the call logger of the common module.
+type callLogger struct {
+	*calllog.Logger
+}
+
+// Create a call logger writing to out.
+func newCallLogger(out io.Writer, config calllog.Config) (*callLogger, error) {
+	l, err := calllog.New(out, config)
+	if err != nil {
+		return nil, err
+	}
+	return &callLogger{l}, nil
+}
+
+// Get the request ID in the outgoing metadata, or generate one and add it to the outgoing metadata.
+func clientRequestId(ctx context.Context) (context.Context, string) {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if values := md.Get(calllog.RequestIdKey); len(values) > 0 {
+			return ctx, values[0]
+		}
+	}
+	requestId := calllog.NewRequestId()
+	return metadata.AppendToOutgoingContext(ctx, calllog.RequestIdKey, requestId), requestId
+}
+
+// Unary interceptor logging the calls, every call is sent with a request ID.
+func (l *callLogger) unaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := l.Now()
+	ctx, requestId := clientRequestId(ctx)
+	sampled := l.Sampled(method)
+	if sampled {
+		l.LogMessage("request sent", requestId, method, req)
+	}
+
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	if sampled && err == nil {
+		l.LogMessage("response received", requestId, method, reply)
+	}
+	l.Finish(requestId, method, start, sampled, err)
+	return err
+}
+
+// Stream interceptor logging the calls with the numbers of the sent and received messages.
+// The call is logged when the stream ends, i.e. the response stream is drained or fails.
+func (l *callLogger) streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := l.Now()
+	ctx, requestId := clientRequestId(ctx)
+	sampled := l.Sampled(method)
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		l.Finish(requestId, method, start, sampled, err)
+		return nil, err
+	}
+	return &loggingClientStream{ClientStream: s, logger: l, desc: desc, requestId: requestId, method: method, start: start, sampled: sampled}, nil
+}
+
+// loggingClientStream counts the messages of the stream and logs their payloads at the debug level.
+type loggingClientStream struct {
+	grpc.ClientStream
+	logger    *callLogger
+	desc      *grpc.StreamDesc
+	requestId string
+	method    string
+	start     time.Time
+	sampled   bool
+	received  int64 // Updated atomically, the messages may be sent and received in different goroutines.
+	sent      int64
+	once      sync.Once
+}
+
+func (s *loggingClientStream) SendMsg(m interface{}) error {
+	if err := s.ClientStream.SendMsg(m); err != nil {
+		return err
+	}
+	atomic.AddInt64(&s.sent, 1)
+	if s.sampled {
+		s.logger.LogMessage("message sent", s.requestId, s.method, m)
+	}
+	return nil
+}
+
+func (s *loggingClientStream) RecvMsg(m interface{}) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		if err == io.EOF {
+			// The response stream is drained, the call is successful.
+			s.end(nil)
+		} else {
+			s.end(err)
+		}
+		return err
+	}
+	atomic.AddInt64(&s.received, 1)
+	if s.sampled {
+		s.logger.LogMessage("message received", s.requestId, s.method, m)
+	}
+	// The call with a single response ends with the response.
+	if !s.desc.ServerStreams {
+		s.end(nil)
+	}
+	return nil
+}
+
+// Log the end of the stream once.
+func (s *loggingClientStream) end(err error) {
+	s.once.Do(func() {
+		s.logger.Finish(s.requestId, s.method, s.start, s.sampled, err,
+			calllog.Field{Key: "sent", Value: atomic.LoadInt64(&s.sent)}, calllog.Field{Key: "received", Value: atomic.LoadInt64(&s.received)})
+	})
+}
@@ -7,10 +7,14 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
+	status "google.golang.org/genproto/googleapis/rpc/status"
+	money "google.golang.org/genproto/googleapis/type/money"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
-	status "google.golang.org/grpc/status"
+	status1 "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -25,15 +29,85 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// The lifecycle of an order:
+// CREATED -> PROCESSING -> SHIPPED -> DELIVERED
+// CREATED or PROCESSING -> CANCELLED
+type OrderStatus int32
+
+const (
+	OrderStatus_CREATED    OrderStatus = 0
+	OrderStatus_PROCESSING OrderStatus = 1
+	OrderStatus_SHIPPED    OrderStatus = 2
+	OrderStatus_DELIVERED  OrderStatus = 3
+	OrderStatus_CANCELLED  OrderStatus = 4
+)
+
+var OrderStatus_name = map[int32]string{
+	0: "CREATED",
+	1: "PROCESSING",
+	2: "SHIPPED",
+	3: "DELIVERED",
+	4: "CANCELLED",
+}
+
+var OrderStatus_value = map[string]int32{
+	"CREATED":    0,
+	"PROCESSING": 1,
+	"SHIPPED":    2,
+	"DELIVERED":  3,
+	"CANCELLED":  4,
+}
+
+func (x OrderStatus) String() string {
+	return proto.EnumName(OrderStatus_name, int32(x))
+}
+
+func (OrderStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{0}
+}
+
+type OrderEvent_Type int32
+
+const (
+	OrderEvent_CREATED   OrderEvent_Type = 0
+	OrderEvent_UPDATED   OrderEvent_Type = 1
+	OrderEvent_PROCESSED OrderEvent_Type = 2
+	OrderEvent_CANCELLED OrderEvent_Type = 3
+)
+
+var OrderEvent_Type_name = map[int32]string{
+	0: "CREATED",
+	1: "UPDATED",
+	2: "PROCESSED",
+	3: "CANCELLED",
+}
+
+var OrderEvent_Type_value = map[string]int32{
+	"CREATED":   0,
+	"UPDATED":   1,
+	"PROCESSED": 2,
+	"CANCELLED": 3,
+}
+
+func (x OrderEvent_Type) String() string {
+	return proto.EnumName(OrderEvent_Type_name, int32(x))
+}
+
+func (OrderEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{15, 0}
+}
+
 type Order struct {
-	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Items                []string `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
-	Description          string   `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Price                float32  `protobuf:"fixed32,4,opt,name=price,proto3" json:"price,omitempty"`
-	Destination          string   `protobuf:"bytes,5,opt,name=destination,proto3" json:"destination,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Id                   string       `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Items                []string     `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	Description          string       `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Destination          string       `protobuf:"bytes,5,opt,name=destination,proto3" json:"destination,omitempty"`
+	Status               OrderStatus  `protobuf:"varint,6,opt,name=status,proto3,enum=ecommerce.OrderStatus" json:"status,omitempty"`
+	Version              int64        `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	Price                *money.Money `protobuf:"bytes,8,opt,name=price,proto3" json:"price,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *Order) Reset()         { *m = Order{} }
@@ -82,27 +156,46 @@ func (m *Order) GetDescription() string {
 	return ""
 }
 
-func (m *Order) GetPrice() float32 {
+func (m *Order) GetDestination() string {
 	if m != nil {
-		return m.Price
+		return m.Destination
+	}
+	return ""
+}
+
+func (m *Order) GetStatus() OrderStatus {
+	if m != nil {
+		return m.Status
+	}
+	return OrderStatus_CREATED
+}
+
+func (m *Order) GetVersion() int64 {
+	if m != nil {
+		return m.Version
 	}
 	return 0
 }
 
-func (m *Order) GetDestination() string {
+func (m *Order) GetPrice() *money.Money {
 	if m != nil {
-		return m.Destination
+		return m.Price
 	}
-	return ""
+	return nil
 }
 
 type CombinedShipment struct {
-	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Status               string   `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
-	OrdersList           []*Order `protobuf:"bytes,3,rep,name=ordersList,proto3" json:"ordersList,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Id                   string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrdersList           []*Order             `protobuf:"bytes,3,rep,name=ordersList,proto3" json:"ordersList,omitempty"`
+	Status               OrderStatus          `protobuf:"varint,4,opt,name=status,proto3,enum=ecommerce.OrderStatus" json:"status,omitempty"`
+	TotalPrice           []*money.Money       `protobuf:"bytes,5,rep,name=totalPrice,proto3" json:"totalPrice,omitempty"`
+	OrderCount           int32                `protobuf:"varint,6,opt,name=orderCount,proto3" json:"orderCount,omitempty"`
+	ItemCount            int32                `protobuf:"varint,7,opt,name=itemCount,proto3" json:"itemCount,omitempty"`
+	CreateTime           *timestamp.Timestamp `protobuf:"bytes,8,opt,name=createTime,proto3" json:"createTime,omitempty"`
+	Destination          string               `protobuf:"bytes,9,opt,name=destination,proto3" json:"destination,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *CombinedShipment) Reset()         { *m = CombinedShipment{} }
@@ -112,75 +205,955 @@ func (*CombinedShipment) Descriptor() ([]byte, []int) {
 	return fileDescriptor_6653354279552460, []int{1}
 }
 
-func (m *CombinedShipment) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_CombinedShipment.Unmarshal(m, b)
+func (m *CombinedShipment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CombinedShipment.Unmarshal(m, b)
+}
+func (m *CombinedShipment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CombinedShipment.Marshal(b, m, deterministic)
+}
+func (m *CombinedShipment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CombinedShipment.Merge(m, src)
+}
+func (m *CombinedShipment) XXX_Size() int {
+	return xxx_messageInfo_CombinedShipment.Size(m)
+}
+func (m *CombinedShipment) XXX_DiscardUnknown() {
+	xxx_messageInfo_CombinedShipment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CombinedShipment proto.InternalMessageInfo
+
+func (m *CombinedShipment) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *CombinedShipment) GetOrdersList() []*Order {
+	if m != nil {
+		return m.OrdersList
+	}
+	return nil
+}
+
+func (m *CombinedShipment) GetStatus() OrderStatus {
+	if m != nil {
+		return m.Status
+	}
+	return OrderStatus_CREATED
+}
+
+func (m *CombinedShipment) GetTotalPrice() []*money.Money {
+	if m != nil {
+		return m.TotalPrice
+	}
+	return nil
+}
+
+func (m *CombinedShipment) GetOrderCount() int32 {
+	if m != nil {
+		return m.OrderCount
+	}
+	return 0
+}
+
+func (m *CombinedShipment) GetItemCount() int32 {
+	if m != nil {
+		return m.ItemCount
+	}
+	return 0
+}
+
+func (m *CombinedShipment) GetCreateTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreateTime
+	}
+	return nil
+}
+
+func (m *CombinedShipment) GetDestination() string {
+	if m != nil {
+		return m.Destination
+	}
+	return ""
+}
+
+type ListShipmentsRequest struct {
+	Destination          string               `protobuf:"bytes,1,opt,name=destination,proto3" json:"destination,omitempty"`
+	Statuses             []OrderStatus        `protobuf:"varint,2,rep,packed,name=statuses,proto3,enum=ecommerce.OrderStatus" json:"statuses,omitempty"`
+	CreatedAfter         *timestamp.Timestamp `protobuf:"bytes,3,opt,name=createdAfter,proto3" json:"createdAfter,omitempty"`
+	CreatedBefore        *timestamp.Timestamp `protobuf:"bytes,4,opt,name=createdBefore,proto3" json:"createdBefore,omitempty"`
+	PageSize             int32                `protobuf:"varint,5,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageToken            string               `protobuf:"bytes,6,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ListShipmentsRequest) Reset()         { *m = ListShipmentsRequest{} }
+func (m *ListShipmentsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListShipmentsRequest) ProtoMessage()    {}
+func (*ListShipmentsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{2}
+}
+
+func (m *ListShipmentsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListShipmentsRequest.Unmarshal(m, b)
+}
+func (m *ListShipmentsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListShipmentsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListShipmentsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListShipmentsRequest.Merge(m, src)
+}
+func (m *ListShipmentsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListShipmentsRequest.Size(m)
+}
+func (m *ListShipmentsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListShipmentsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListShipmentsRequest proto.InternalMessageInfo
+
+func (m *ListShipmentsRequest) GetDestination() string {
+	if m != nil {
+		return m.Destination
+	}
+	return ""
+}
+
+func (m *ListShipmentsRequest) GetStatuses() []OrderStatus {
+	if m != nil {
+		return m.Statuses
+	}
+	return nil
+}
+
+func (m *ListShipmentsRequest) GetCreatedAfter() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreatedAfter
+	}
+	return nil
+}
+
+func (m *ListShipmentsRequest) GetCreatedBefore() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreatedBefore
+	}
+	return nil
+}
+
+func (m *ListShipmentsRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListShipmentsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type ListShipmentsResponse struct {
+	Shipments            []*CombinedShipment `protobuf:"bytes,1,rep,name=shipments,proto3" json:"shipments,omitempty"`
+	NextPageToken        string              `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *ListShipmentsResponse) Reset()         { *m = ListShipmentsResponse{} }
+func (m *ListShipmentsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListShipmentsResponse) ProtoMessage()    {}
+func (*ListShipmentsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{3}
+}
+
+func (m *ListShipmentsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListShipmentsResponse.Unmarshal(m, b)
+}
+func (m *ListShipmentsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListShipmentsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListShipmentsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListShipmentsResponse.Merge(m, src)
+}
+func (m *ListShipmentsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListShipmentsResponse.Size(m)
+}
+func (m *ListShipmentsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListShipmentsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListShipmentsResponse proto.InternalMessageInfo
+
+func (m *ListShipmentsResponse) GetShipments() []*CombinedShipment {
+	if m != nil {
+		return m.Shipments
+	}
+	return nil
+}
+
+func (m *ListShipmentsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+type SearchOrdersRequest struct {
+	Items                string       `protobuf:"bytes,1,opt,name=items,proto3" json:"items,omitempty"`
+	Destination          string       `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	Description          string       `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	MinPrice             *money.Money `protobuf:"bytes,6,opt,name=minPrice,proto3" json:"minPrice,omitempty"`
+	MaxPrice             *money.Money `protobuf:"bytes,7,opt,name=maxPrice,proto3" json:"maxPrice,omitempty"`
+	MaxResults           int32        `protobuf:"varint,8,opt,name=maxResults,proto3" json:"maxResults,omitempty"`
+	PageToken            string       `protobuf:"bytes,9,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *SearchOrdersRequest) Reset()         { *m = SearchOrdersRequest{} }
+func (m *SearchOrdersRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchOrdersRequest) ProtoMessage()    {}
+func (*SearchOrdersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{4}
+}
+
+func (m *SearchOrdersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchOrdersRequest.Unmarshal(m, b)
+}
+func (m *SearchOrdersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchOrdersRequest.Marshal(b, m, deterministic)
+}
+func (m *SearchOrdersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchOrdersRequest.Merge(m, src)
+}
+func (m *SearchOrdersRequest) XXX_Size() int {
+	return xxx_messageInfo_SearchOrdersRequest.Size(m)
+}
+func (m *SearchOrdersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchOrdersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchOrdersRequest proto.InternalMessageInfo
+
+func (m *SearchOrdersRequest) GetItems() string {
+	if m != nil {
+		return m.Items
+	}
+	return ""
+}
+
+func (m *SearchOrdersRequest) GetDestination() string {
+	if m != nil {
+		return m.Destination
+	}
+	return ""
+}
+
+func (m *SearchOrdersRequest) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *SearchOrdersRequest) GetMinPrice() *money.Money {
+	if m != nil {
+		return m.MinPrice
+	}
+	return nil
+}
+
+func (m *SearchOrdersRequest) GetMaxPrice() *money.Money {
+	if m != nil {
+		return m.MaxPrice
+	}
+	return nil
+}
+
+func (m *SearchOrdersRequest) GetMaxResults() int32 {
+	if m != nil {
+		return m.MaxResults
+	}
+	return 0
+}
+
+func (m *SearchOrdersRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type UpdateOrderRequest struct {
+	Order                *Order                `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	UpdateMask           *field_mask.FieldMask `protobuf:"bytes,2,opt,name=updateMask,proto3" json:"updateMask,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *UpdateOrderRequest) Reset()         { *m = UpdateOrderRequest{} }
+func (m *UpdateOrderRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateOrderRequest) ProtoMessage()    {}
+func (*UpdateOrderRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{5}
+}
+
+func (m *UpdateOrderRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateOrderRequest.Unmarshal(m, b)
+}
+func (m *UpdateOrderRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateOrderRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdateOrderRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateOrderRequest.Merge(m, src)
+}
+func (m *UpdateOrderRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateOrderRequest.Size(m)
+}
+func (m *UpdateOrderRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateOrderRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateOrderRequest proto.InternalMessageInfo
+
+func (m *UpdateOrderRequest) GetOrder() *Order {
+	if m != nil {
+		return m.Order
+	}
+	return nil
+}
+
+func (m *UpdateOrderRequest) GetUpdateMask() *field_mask.FieldMask {
+	if m != nil {
+		return m.UpdateMask
+	}
+	return nil
+}
+
+type UpdateOrdersResponse struct {
+	Results              []*UpdateOrderResult `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *UpdateOrdersResponse) Reset()         { *m = UpdateOrdersResponse{} }
+func (m *UpdateOrdersResponse) String() string { return proto.CompactTextString(m) }
+func (*UpdateOrdersResponse) ProtoMessage()    {}
+func (*UpdateOrdersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{6}
+}
+
+func (m *UpdateOrdersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateOrdersResponse.Unmarshal(m, b)
+}
+func (m *UpdateOrdersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateOrdersResponse.Marshal(b, m, deterministic)
+}
+func (m *UpdateOrdersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateOrdersResponse.Merge(m, src)
+}
+func (m *UpdateOrdersResponse) XXX_Size() int {
+	return xxx_messageInfo_UpdateOrdersResponse.Size(m)
+}
+func (m *UpdateOrdersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateOrdersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateOrdersResponse proto.InternalMessageInfo
+
+func (m *UpdateOrdersResponse) GetResults() []*UpdateOrderResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type UpdateOrderResult struct {
+	OrderId              string         `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	Status               *status.Status `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Version              int64          `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *UpdateOrderResult) Reset()         { *m = UpdateOrderResult{} }
+func (m *UpdateOrderResult) String() string { return proto.CompactTextString(m) }
+func (*UpdateOrderResult) ProtoMessage()    {}
+func (*UpdateOrderResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{7}
+}
+
+func (m *UpdateOrderResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateOrderResult.Unmarshal(m, b)
+}
+func (m *UpdateOrderResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateOrderResult.Marshal(b, m, deterministic)
+}
+func (m *UpdateOrderResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateOrderResult.Merge(m, src)
+}
+func (m *UpdateOrderResult) XXX_Size() int {
+	return xxx_messageInfo_UpdateOrderResult.Size(m)
+}
+func (m *UpdateOrderResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateOrderResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateOrderResult proto.InternalMessageInfo
+
+func (m *UpdateOrderResult) GetOrderId() string {
+	if m != nil {
+		return m.OrderId
+	}
+	return ""
+}
+
+func (m *UpdateOrderResult) GetStatus() *status.Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+func (m *UpdateOrderResult) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type AddOrdersResponse struct {
+	Results              []*AddOrderResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *AddOrdersResponse) Reset()         { *m = AddOrdersResponse{} }
+func (m *AddOrdersResponse) String() string { return proto.CompactTextString(m) }
+func (*AddOrdersResponse) ProtoMessage()    {}
+func (*AddOrdersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{8}
+}
+
+func (m *AddOrdersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddOrdersResponse.Unmarshal(m, b)
+}
+func (m *AddOrdersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddOrdersResponse.Marshal(b, m, deterministic)
+}
+func (m *AddOrdersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddOrdersResponse.Merge(m, src)
+}
+func (m *AddOrdersResponse) XXX_Size() int {
+	return xxx_messageInfo_AddOrdersResponse.Size(m)
+}
+func (m *AddOrdersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddOrdersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddOrdersResponse proto.InternalMessageInfo
+
+func (m *AddOrdersResponse) GetResults() []*AddOrderResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type AddOrderResult struct {
+	OrderId              string         `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	Status               *status.Status `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Version              int64          `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *AddOrderResult) Reset()         { *m = AddOrderResult{} }
+func (m *AddOrderResult) String() string { return proto.CompactTextString(m) }
+func (*AddOrderResult) ProtoMessage()    {}
+func (*AddOrderResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{9}
+}
+
+func (m *AddOrderResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddOrderResult.Unmarshal(m, b)
+}
+func (m *AddOrderResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddOrderResult.Marshal(b, m, deterministic)
+}
+func (m *AddOrderResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddOrderResult.Merge(m, src)
+}
+func (m *AddOrderResult) XXX_Size() int {
+	return xxx_messageInfo_AddOrderResult.Size(m)
+}
+func (m *AddOrderResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddOrderResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddOrderResult proto.InternalMessageInfo
+
+func (m *AddOrderResult) GetOrderId() string {
+	if m != nil {
+		return m.OrderId
+	}
+	return ""
+}
+
+func (m *AddOrderResult) GetStatus() *status.Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+func (m *AddOrderResult) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type BatchGetOrdersRequest struct {
+	OrderIds             []string `protobuf:"bytes,1,rep,name=orderIds,proto3" json:"orderIds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchGetOrdersRequest) Reset()         { *m = BatchGetOrdersRequest{} }
+func (m *BatchGetOrdersRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchGetOrdersRequest) ProtoMessage()    {}
+func (*BatchGetOrdersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{10}
+}
+
+func (m *BatchGetOrdersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchGetOrdersRequest.Unmarshal(m, b)
+}
+func (m *BatchGetOrdersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchGetOrdersRequest.Marshal(b, m, deterministic)
+}
+func (m *BatchGetOrdersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchGetOrdersRequest.Merge(m, src)
+}
+func (m *BatchGetOrdersRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchGetOrdersRequest.Size(m)
+}
+func (m *BatchGetOrdersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchGetOrdersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchGetOrdersRequest proto.InternalMessageInfo
+
+func (m *BatchGetOrdersRequest) GetOrderIds() []string {
+	if m != nil {
+		return m.OrderIds
+	}
+	return nil
+}
+
+type BatchGetOrdersResponse struct {
+	Orders               []*Order `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	MissingIds           []string `protobuf:"bytes,2,rep,name=missingIds,proto3" json:"missingIds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchGetOrdersResponse) Reset()         { *m = BatchGetOrdersResponse{} }
+func (m *BatchGetOrdersResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchGetOrdersResponse) ProtoMessage()    {}
+func (*BatchGetOrdersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{11}
+}
+
+func (m *BatchGetOrdersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchGetOrdersResponse.Unmarshal(m, b)
+}
+func (m *BatchGetOrdersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchGetOrdersResponse.Marshal(b, m, deterministic)
+}
+func (m *BatchGetOrdersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchGetOrdersResponse.Merge(m, src)
+}
+func (m *BatchGetOrdersResponse) XXX_Size() int {
+	return xxx_messageInfo_BatchGetOrdersResponse.Size(m)
+}
+func (m *BatchGetOrdersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchGetOrdersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchGetOrdersResponse proto.InternalMessageInfo
+
+func (m *BatchGetOrdersResponse) GetOrders() []*Order {
+	if m != nil {
+		return m.Orders
+	}
+	return nil
+}
+
+func (m *BatchGetOrdersResponse) GetMissingIds() []string {
+	if m != nil {
+		return m.MissingIds
+	}
+	return nil
+}
+
+type ProcessOrdersResponse struct {
+	// Types that are valid to be assigned to Result:
+	//	*ProcessOrdersResponse_Shipment
+	//	*ProcessOrdersResponse_Error
+	Result               isProcessOrdersResponse_Result `protobuf_oneof:"result"`
+	XXX_NoUnkeyedLiteral struct{}                       `json:"-"`
+	XXX_unrecognized     []byte                         `json:"-"`
+	XXX_sizecache        int32                          `json:"-"`
+}
+
+func (m *ProcessOrdersResponse) Reset()         { *m = ProcessOrdersResponse{} }
+func (m *ProcessOrdersResponse) String() string { return proto.CompactTextString(m) }
+func (*ProcessOrdersResponse) ProtoMessage()    {}
+func (*ProcessOrdersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{12}
+}
+
+func (m *ProcessOrdersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProcessOrdersResponse.Unmarshal(m, b)
+}
+func (m *ProcessOrdersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProcessOrdersResponse.Marshal(b, m, deterministic)
+}
+func (m *ProcessOrdersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProcessOrdersResponse.Merge(m, src)
+}
+func (m *ProcessOrdersResponse) XXX_Size() int {
+	return xxx_messageInfo_ProcessOrdersResponse.Size(m)
+}
+func (m *ProcessOrdersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProcessOrdersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProcessOrdersResponse proto.InternalMessageInfo
+
+type isProcessOrdersResponse_Result interface {
+	isProcessOrdersResponse_Result()
+}
+
+type ProcessOrdersResponse_Shipment struct {
+	Shipment *CombinedShipment `protobuf:"bytes,1,opt,name=shipment,proto3,oneof"`
+}
+
+type ProcessOrdersResponse_Error struct {
+	Error *ProcessingError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ProcessOrdersResponse_Shipment) isProcessOrdersResponse_Result() {}
+
+func (*ProcessOrdersResponse_Error) isProcessOrdersResponse_Result() {}
+
+func (m *ProcessOrdersResponse) GetResult() isProcessOrdersResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *ProcessOrdersResponse) GetShipment() *CombinedShipment {
+	if x, ok := m.GetResult().(*ProcessOrdersResponse_Shipment); ok {
+		return x.Shipment
+	}
+	return nil
+}
+
+func (m *ProcessOrdersResponse) GetError() *ProcessingError {
+	if x, ok := m.GetResult().(*ProcessOrdersResponse_Error); ok {
+		return x.Error
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*ProcessOrdersResponse) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*ProcessOrdersResponse_Shipment)(nil),
+		(*ProcessOrdersResponse_Error)(nil),
+	}
+}
+
+type ProcessingError struct {
+	OrderId              string         `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	Status               *status.Status `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ProcessingError) Reset()         { *m = ProcessingError{} }
+func (m *ProcessingError) String() string { return proto.CompactTextString(m) }
+func (*ProcessingError) ProtoMessage()    {}
+func (*ProcessingError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{13}
+}
+
+func (m *ProcessingError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProcessingError.Unmarshal(m, b)
+}
+func (m *ProcessingError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProcessingError.Marshal(b, m, deterministic)
+}
+func (m *ProcessingError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProcessingError.Merge(m, src)
+}
+func (m *ProcessingError) XXX_Size() int {
+	return xxx_messageInfo_ProcessingError.Size(m)
+}
+func (m *ProcessingError) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProcessingError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProcessingError proto.InternalMessageInfo
+
+func (m *ProcessingError) GetOrderId() string {
+	if m != nil {
+		return m.OrderId
+	}
+	return ""
+}
+
+func (m *ProcessingError) GetStatus() *status.Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type WatchOrdersRequest struct {
+	ResumeToken          string   `protobuf:"bytes,1,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchOrdersRequest) Reset()         { *m = WatchOrdersRequest{} }
+func (m *WatchOrdersRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchOrdersRequest) ProtoMessage()    {}
+func (*WatchOrdersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{14}
+}
+
+func (m *WatchOrdersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchOrdersRequest.Unmarshal(m, b)
+}
+func (m *WatchOrdersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchOrdersRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchOrdersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchOrdersRequest.Merge(m, src)
+}
+func (m *WatchOrdersRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchOrdersRequest.Size(m)
+}
+func (m *WatchOrdersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchOrdersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchOrdersRequest proto.InternalMessageInfo
+
+func (m *WatchOrdersRequest) GetResumeToken() string {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return ""
+}
+
+type OrderEvent struct {
+	ResumeToken          string               `protobuf:"bytes,1,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	Type                 OrderEvent_Type      `protobuf:"varint,2,opt,name=type,proto3,enum=ecommerce.OrderEvent_Type" json:"type,omitempty"`
+	Before               *Order               `protobuf:"bytes,3,opt,name=before,proto3" json:"before,omitempty"`
+	After                *Order               `protobuf:"bytes,4,opt,name=after,proto3" json:"after,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *OrderEvent) Reset()         { *m = OrderEvent{} }
+func (m *OrderEvent) String() string { return proto.CompactTextString(m) }
+func (*OrderEvent) ProtoMessage()    {}
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6653354279552460, []int{15}
+}
+
+func (m *OrderEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrderEvent.Unmarshal(m, b)
 }
-func (m *CombinedShipment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_CombinedShipment.Marshal(b, m, deterministic)
+func (m *OrderEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrderEvent.Marshal(b, m, deterministic)
 }
-func (m *CombinedShipment) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_CombinedShipment.Merge(m, src)
+func (m *OrderEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrderEvent.Merge(m, src)
 }
-func (m *CombinedShipment) XXX_Size() int {
-	return xxx_messageInfo_CombinedShipment.Size(m)
+func (m *OrderEvent) XXX_Size() int {
+	return xxx_messageInfo_OrderEvent.Size(m)
 }
-func (m *CombinedShipment) XXX_DiscardUnknown() {
-	xxx_messageInfo_CombinedShipment.DiscardUnknown(m)
+func (m *OrderEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrderEvent.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_CombinedShipment proto.InternalMessageInfo
+var xxx_messageInfo_OrderEvent proto.InternalMessageInfo
 
-func (m *CombinedShipment) GetId() string {
+func (m *OrderEvent) GetResumeToken() string {
 	if m != nil {
-		return m.Id
+		return m.ResumeToken
 	}
 	return ""
 }
 
-func (m *CombinedShipment) GetStatus() string {
+func (m *OrderEvent) GetType() OrderEvent_Type {
 	if m != nil {
-		return m.Status
+		return m.Type
 	}
-	return ""
+	return OrderEvent_CREATED
 }
 
-func (m *CombinedShipment) GetOrdersList() []*Order {
+func (m *OrderEvent) GetBefore() *Order {
 	if m != nil {
-		return m.OrdersList
+		return m.Before
+	}
+	return nil
+}
+
+func (m *OrderEvent) GetAfter() *Order {
+	if m != nil {
+		return m.After
+	}
+	return nil
+}
+
+func (m *OrderEvent) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
 	}
 	return nil
 }
 
 func init() {
+	proto.RegisterEnum("ecommerce.OrderStatus", OrderStatus_name, OrderStatus_value)
+	proto.RegisterEnum("ecommerce.OrderEvent_Type", OrderEvent_Type_name, OrderEvent_Type_value)
 	proto.RegisterType((*Order)(nil), "ecommerce.Order")
 	proto.RegisterType((*CombinedShipment)(nil), "ecommerce.CombinedShipment")
+	proto.RegisterType((*ListShipmentsRequest)(nil), "ecommerce.ListShipmentsRequest")
+	proto.RegisterType((*ListShipmentsResponse)(nil), "ecommerce.ListShipmentsResponse")
+	proto.RegisterType((*SearchOrdersRequest)(nil), "ecommerce.SearchOrdersRequest")
+	proto.RegisterType((*UpdateOrderRequest)(nil), "ecommerce.UpdateOrderRequest")
+	proto.RegisterType((*UpdateOrdersResponse)(nil), "ecommerce.UpdateOrdersResponse")
+	proto.RegisterType((*UpdateOrderResult)(nil), "ecommerce.UpdateOrderResult")
+	proto.RegisterType((*AddOrdersResponse)(nil), "ecommerce.AddOrdersResponse")
+	proto.RegisterType((*AddOrderResult)(nil), "ecommerce.AddOrderResult")
+	proto.RegisterType((*BatchGetOrdersRequest)(nil), "ecommerce.BatchGetOrdersRequest")
+	proto.RegisterType((*BatchGetOrdersResponse)(nil), "ecommerce.BatchGetOrdersResponse")
+	proto.RegisterType((*ProcessOrdersResponse)(nil), "ecommerce.ProcessOrdersResponse")
+	proto.RegisterType((*ProcessingError)(nil), "ecommerce.ProcessingError")
+	proto.RegisterType((*WatchOrdersRequest)(nil), "ecommerce.WatchOrdersRequest")
+	proto.RegisterType((*OrderEvent)(nil), "ecommerce.OrderEvent")
 }
 
 func init() { proto.RegisterFile("order_management.proto", fileDescriptor_6653354279552460) }
 
 var fileDescriptor_6653354279552460 = []byte{
-	// 330 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0x5f, 0x4b, 0xc3, 0x30,
-	0x14, 0xc5, 0x49, 0xeb, 0x86, 0xbb, 0xf3, 0xcf, 0x08, 0x32, 0xca, 0x14, 0x29, 0x7b, 0xea, 0x53,
-	0x37, 0xe6, 0x9b, 0x4f, 0x82, 0xaf, 0xfe, 0x81, 0x0e, 0x7c, 0x95, 0xac, 0xb9, 0x76, 0x81, 0xb5,
-	0x09, 0x49, 0x8a, 0x1f, 0xc1, 0x4f, 0xe9, 0x77, 0x91, 0x24, 0xdb, 0x18, 0x16, 0xc4, 0x3d, 0xde,
-	0xdb, 0xf3, 0x3b, 0x3d, 0xf7, 0x04, 0xc6, 0x52, 0x73, 0xd4, 0xef, 0x35, 0x6b, 0x58, 0x85, 0x35,
-	0x36, 0x36, 0x57, 0x5a, 0x5a, 0x49, 0x07, 0x58, 0xca, 0xba, 0x46, 0x5d, 0xe2, 0xe4, 0xb6, 0x92,
-	0xb2, 0xda, 0xe0, 0xcc, 0x7f, 0x58, 0xb5, 0x1f, 0xb3, 0x4f, 0xcd, 0x94, 0x42, 0x6d, 0x82, 0x74,
-	0xfa, 0x45, 0xa0, 0xf7, 0xea, 0x5c, 0xe8, 0x05, 0x44, 0x82, 0x27, 0x24, 0x25, 0xd9, 0xa0, 0x88,
-	0x04, 0xa7, 0x57, 0xd0, 0x13, 0x16, 0x6b, 0x93, 0x44, 0x69, 0x9c, 0x0d, 0x8a, 0x30, 0xd0, 0x14,
-	0x86, 0x1c, 0x4d, 0xa9, 0x85, 0xb2, 0x42, 0x36, 0x49, 0xec, 0xe5, 0x87, 0x2b, 0xc7, 0x29, 0x2d,
-	0x4a, 0x4c, 0x4e, 0x52, 0x92, 0x45, 0x45, 0x18, 0xb6, 0x9c, 0x15, 0x0d, 0xf3, 0x5c, 0x6f, 0xcf,
-	0xed, 0x56, 0xd3, 0x0d, 0x8c, 0x1e, 0x65, 0xbd, 0x12, 0x0d, 0xf2, 0xe5, 0x5a, 0x28, 0x77, 0x4e,
-	0x27, 0xd3, 0x18, 0xfa, 0xc6, 0x32, 0xdb, 0xba, 0x50, 0x6e, 0xb7, 0x9d, 0xe8, 0x1c, 0xc0, 0x57,
-	0x61, 0x9e, 0x84, 0xb1, 0x49, 0x9c, 0xc6, 0xd9, 0x70, 0x31, 0xca, 0xf7, 0x2d, 0xe4, 0xfe, 0xc2,
-	0xe2, 0x40, 0xb3, 0xf8, 0x8e, 0xe0, 0xd2, 0x6f, 0x9f, 0xf7, 0xe5, 0xd1, 0x7b, 0x38, 0x65, 0x9c,
-	0x87, 0x36, 0x3a, 0xf4, 0xe4, 0x26, 0x0f, 0x55, 0xe6, 0xbb, 0x2a, 0xf3, 0xa5, 0xd5, 0xa2, 0xa9,
-	0xde, 0xd8, 0xa6, 0x45, 0xc7, 0x56, 0x68, 0x03, 0xfb, 0xa7, 0x72, 0xd2, 0x71, 0xa6, 0x0f, 0x70,
-	0x66, 0x90, 0xe9, 0x72, 0xed, 0x47, 0x73, 0x2c, 0x3f, 0x27, 0xce, 0xa1, 0x55, 0x9c, 0x59, 0xdc,
-	0x3a, 0x1c, 0x99, 0x3e, 0x23, 0xf4, 0x05, 0xce, 0x95, 0x96, 0x25, 0x1a, 0xf3, 0xaf, 0x10, 0xd7,
-	0x07, 0x3f, 0xf8, 0xfd, 0x6a, 0x19, 0x99, 0x93, 0x55, 0xdf, 0x63, 0x77, 0x3f, 0x01, 0x00, 0x00,
-	0xff, 0xff, 0xed, 0x7a, 0x7f, 0xf8, 0xa3, 0x02, 0x00, 0x00,
+	// 1257 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0xcb, 0x6e, 0xdb, 0x46,
+	0x17, 0x0e, 0x6f, 0x16, 0x79, 0x14, 0x3b, 0xca, 0x24, 0xce, 0xaf, 0x5f, 0xcd, 0x85, 0x25, 0x8a,
+	0x42, 0xc8, 0x82, 0x36, 0x14, 0x20, 0x40, 0x02, 0x24, 0xa8, 0x2d, 0x2b, 0xb1, 0x0d, 0x27, 0x11,
+	0x46, 0x76, 0x02, 0x74, 0x13, 0x50, 0xd4, 0x58, 0x21, 0x22, 0x5e, 0xca, 0xa1, 0x12, 0xa5, 0x4f,
+	0xd1, 0x6d, 0x77, 0x5d, 0x76, 0xdf, 0xb7, 0xe9, 0x2b, 0xf4, 0x25, 0x8a, 0x19, 0x5e, 0x34, 0x24,
+	0x25, 0x39, 0x28, 0xda, 0x1d, 0x67, 0xce, 0x37, 0x67, 0xce, 0x77, 0x2e, 0xdf, 0x10, 0xee, 0x84,
+	0xf1, 0x84, 0xc4, 0xef, 0x7d, 0x27, 0x70, 0xa6, 0xc4, 0x27, 0x41, 0x62, 0x47, 0x71, 0x98, 0x84,
+	0xc8, 0x20, 0x6e, 0xe8, 0xfb, 0x24, 0x76, 0x49, 0xc7, 0x9c, 0x86, 0xe1, 0x74, 0x46, 0xf6, 0xb8,
+	0x61, 0x3c, 0xbf, 0xdc, 0xbb, 0xf4, 0xc8, 0x6c, 0xf2, 0xde, 0x77, 0xe8, 0xc7, 0x14, 0xdc, 0x79,
+	0x50, 0x45, 0x24, 0x9e, 0x4f, 0x68, 0xe2, 0xf8, 0x51, 0x06, 0xb8, 0x5f, 0x05, 0x7c, 0x8e, 0x9d,
+	0x28, 0x22, 0x31, 0xcd, 0xec, 0xff, 0xcb, 0xec, 0x71, 0xe4, 0xee, 0xd1, 0xc4, 0x49, 0xe6, 0x55,
+	0x43, 0xf2, 0x25, 0x22, 0x7b, 0x7e, 0x18, 0x90, 0x2f, 0xa9, 0xc1, 0xfa, 0x4b, 0x02, 0xed, 0x0d,
+	0x0b, 0x1d, 0xed, 0x80, 0xec, 0x4d, 0xda, 0x92, 0x29, 0x75, 0x0d, 0x2c, 0x7b, 0x13, 0x74, 0x1b,
+	0x34, 0x2f, 0x21, 0x3e, 0x6d, 0xcb, 0xa6, 0xd2, 0x35, 0x70, 0xba, 0x40, 0x26, 0x34, 0x27, 0x84,
+	0xba, 0xb1, 0x17, 0x25, 0x5e, 0x18, 0xb4, 0x15, 0x0e, 0x17, 0xb7, 0x32, 0x44, 0xe2, 0x05, 0x0e,
+	0x47, 0x68, 0x05, 0x22, 0xdf, 0x42, 0x36, 0x6c, 0xa5, 0xc1, 0xb5, 0xb7, 0x4c, 0xa9, 0xbb, 0xd3,
+	0xbb, 0x63, 0x17, 0x49, 0xb2, 0x79, 0x2c, 0x23, 0x6e, 0xc5, 0x19, 0x0a, 0xb5, 0xa1, 0xf1, 0x89,
+	0xc4, 0x94, 0x79, 0x6b, 0x98, 0x52, 0x57, 0xc1, 0xf9, 0x12, 0x75, 0x41, 0x8b, 0x62, 0xcf, 0x25,
+	0x6d, 0xdd, 0x94, 0xba, 0xcd, 0x1e, 0xb2, 0x53, 0x9a, 0x36, 0xa3, 0x69, 0xbf, 0x62, 0x34, 0x71,
+	0x0a, 0x38, 0x55, 0x75, 0xb5, 0xa5, 0x59, 0x7f, 0xca, 0xd0, 0xea, 0x87, 0xfe, 0xd8, 0x0b, 0xc8,
+	0x64, 0xf4, 0xc1, 0x8b, 0x58, 0xa1, 0x6a, 0xc4, 0xf7, 0x01, 0x78, 0x31, 0xe9, 0x99, 0x47, 0x93,
+	0xb6, 0x62, 0x2a, 0xdd, 0x66, 0xaf, 0x55, 0x0d, 0x11, 0x0b, 0x18, 0x81, 0x90, 0xfa, 0x55, 0x84,
+	0x7a, 0x00, 0x49, 0x98, 0x38, 0xb3, 0x21, 0x8f, 0x5d, 0x33, 0x95, 0x35, 0xb1, 0x0b, 0x28, 0x74,
+	0x3f, 0x8b, 0xaa, 0x1f, 0xce, 0x83, 0x84, 0x27, 0x4e, 0xc3, 0xc2, 0x0e, 0xba, 0x0b, 0x06, 0xab,
+	0x50, 0x6a, 0x6e, 0x70, 0xf3, 0x72, 0x03, 0x3d, 0x05, 0x70, 0x63, 0xe2, 0x24, 0xe4, 0xdc, 0xf3,
+	0xf3, 0x6c, 0x75, 0xf2, 0x1b, 0xf3, 0x6e, 0xb2, 0xcf, 0xf3, 0x76, 0xc3, 0x02, 0xba, 0x5a, 0x50,
+	0xa3, 0x56, 0xd0, 0x53, 0x55, 0x97, 0x5b, 0x8a, 0xf5, 0xbb, 0x0c, 0xb7, 0x59, 0x3a, 0xf2, 0xc4,
+	0x52, 0x4c, 0x7e, 0x9a, 0x13, 0x9a, 0x54, 0x1d, 0x48, 0xf5, 0x8e, 0xe8, 0x81, 0x9e, 0xa6, 0x86,
+	0xa4, 0xed, 0xb6, 0x3e, 0x85, 0x05, 0x0e, 0x3d, 0x87, 0xeb, 0x69, 0x90, 0x93, 0x83, 0xcb, 0x84,
+	0xc4, 0x6d, 0xe5, 0x4a, 0x52, 0x25, 0x3c, 0xfa, 0x01, 0xb6, 0xb3, 0xf5, 0x21, 0xb9, 0x0c, 0x63,
+	0xd2, 0x56, 0xaf, 0x74, 0x50, 0x3e, 0x80, 0x3a, 0xa0, 0x47, 0xce, 0x94, 0x8c, 0xbc, 0x9f, 0x09,
+	0x6f, 0x73, 0x0d, 0x17, 0x6b, 0x56, 0x0e, 0xf6, 0x7d, 0x1e, 0x7e, 0x24, 0x01, 0xaf, 0x96, 0x81,
+	0x97, 0x1b, 0xd6, 0x02, 0x76, 0x2b, 0x99, 0xa2, 0x51, 0x18, 0x50, 0x82, 0x9e, 0x80, 0x41, 0xf3,
+	0xcd, 0xb6, 0xc4, 0x1b, 0xe3, 0x1b, 0x21, 0x13, 0xd5, 0xde, 0xc5, 0x4b, 0x34, 0xfa, 0x0e, 0xb6,
+	0x03, 0xb2, 0x48, 0x86, 0xc5, 0xad, 0x32, 0xbf, 0xb5, 0xbc, 0x69, 0xfd, 0x2a, 0xc3, 0xad, 0x11,
+	0x71, 0x62, 0xf7, 0x03, 0xcf, 0x6a, 0x51, 0xa3, 0x62, 0xda, 0xd3, 0xea, 0x94, 0xa6, 0xbd, 0xa8,
+	0x9c, 0x5c, 0xaf, 0x5c, 0x45, 0x0f, 0xb4, 0xba, 0x1e, 0xd8, 0xa0, 0xfb, 0x5e, 0x90, 0xb6, 0xfa,
+	0xd6, 0xda, 0x31, 0x2d, 0x30, 0x1c, 0xef, 0x2c, 0x52, 0x7c, 0x63, 0x03, 0xde, 0x59, 0x14, 0x83,
+	0xe1, 0x3b, 0x0b, 0x4c, 0xe8, 0x7c, 0x96, 0x50, 0xde, 0xda, 0x1a, 0x16, 0x76, 0xca, 0x95, 0x30,
+	0x2a, 0x95, 0x38, 0x55, 0x75, 0xa5, 0xa5, 0x66, 0xea, 0xb0, 0x00, 0x74, 0x11, 0x4d, 0x9c, 0x84,
+	0xa4, 0x13, 0x9e, 0x65, 0xe6, 0x7b, 0xd0, 0xf8, 0x98, 0xf1, 0xcc, 0xac, 0x52, 0x82, 0xd4, 0xcc,
+	0x46, 0x6c, 0xce, 0x4f, 0xbf, 0x72, 0xe8, 0xc7, 0xb6, 0xbc, 0xa6, 0x99, 0x5e, 0x30, 0xcd, 0x67,
+	0x08, 0x2c, 0xa0, 0xad, 0x1f, 0xe1, 0xb6, 0x70, 0xf3, 0xb2, 0x1d, 0x1e, 0x43, 0x23, 0xce, 0x88,
+	0xa5, 0x3a, 0x74, 0x57, 0xb8, 0xbd, 0x14, 0x2b, 0x03, 0xe1, 0x1c, 0x7c, 0xaa, 0xea, 0x52, 0x4b,
+	0xce, 0xc6, 0x92, 0xc2, 0xcd, 0x1a, 0x92, 0x49, 0x2a, 0x8f, 0xfa, 0x24, 0x17, 0xbe, 0x7c, 0x89,
+	0x1e, 0x16, 0x5a, 0x26, 0x97, 0x93, 0x1f, 0x47, 0xae, 0xbd, 0x5e, 0x98, 0x95, 0x92, 0x30, 0x5b,
+	0xc7, 0x70, 0xf3, 0x60, 0x32, 0xa9, 0xb0, 0x79, 0xb4, 0x64, 0x93, 0xb6, 0xf6, 0xff, 0x05, 0x36,
+	0x39, 0xbc, 0x42, 0xc5, 0x8a, 0x60, 0xa7, 0x6c, 0xfa, 0xcf, 0x63, 0x7f, 0x04, 0xbb, 0x87, 0x4e,
+	0xe2, 0x7e, 0x78, 0x49, 0x92, 0xf2, 0x8c, 0x74, 0x40, 0xcf, 0x6e, 0x4a, 0x09, 0x18, 0xb8, 0x58,
+	0x5b, 0x63, 0xb8, 0x53, 0x3d, 0x94, 0xb1, 0xee, 0xc2, 0x16, 0x47, 0xe5, 0xa4, 0xeb, 0x0d, 0x94,
+	0xd9, 0x79, 0x27, 0x7b, 0x94, 0x7a, 0xc1, 0xf4, 0x64, 0x92, 0xea, 0xa0, 0x81, 0x85, 0x1d, 0xeb,
+	0x17, 0x09, 0x76, 0x87, 0x71, 0xe8, 0x12, 0x4a, 0x2b, 0x77, 0x3c, 0x01, 0x3d, 0x17, 0x82, 0xac,
+	0x4d, 0x37, 0xa9, 0xc6, 0xf1, 0x35, 0x5c, 0xc0, 0x51, 0x0f, 0x34, 0x12, 0xc7, 0x61, 0x5c, 0x74,
+	0xec, 0xf2, 0x5c, 0x76, 0x97, 0x17, 0x4c, 0x07, 0x0c, 0x71, 0x7c, 0x0d, 0xa7, 0xd0, 0x43, 0x1d,
+	0xb6, 0xd2, 0xf2, 0x58, 0xef, 0xe0, 0x46, 0x05, 0xf5, 0xef, 0x94, 0xc7, 0x7a, 0x0c, 0xe8, 0x1d,
+	0xcb, 0x67, 0xb9, 0x02, 0x26, 0x34, 0xd9, 0xc5, 0x7e, 0x36, 0xcd, 0xd9, 0x4b, 0x22, 0x6c, 0x59,
+	0xbf, 0xc9, 0x00, 0xfc, 0xcc, 0xe0, 0x13, 0x09, 0xbe, 0xe2, 0x00, 0xb2, 0x41, 0x65, 0xb2, 0xc2,
+	0x43, 0xda, 0x29, 0xd1, 0x5f, 0xba, 0xb1, 0xcf, 0xbf, 0x44, 0x04, 0x73, 0x1c, 0x2b, 0xe7, 0x38,
+	0x7d, 0x2f, 0x94, 0x35, 0x7a, 0x90, 0xd9, 0x99, 0x70, 0x38, 0xfc, 0x65, 0x52, 0xd7, 0x09, 0x07,
+	0x37, 0xf3, 0x08, 0xd8, 0xab, 0xac, 0x5d, 0xf9, 0xfe, 0x70, 0x9c, 0xf5, 0x1c, 0x54, 0x16, 0x0f,
+	0x6a, 0x42, 0xa3, 0x8f, 0x07, 0x07, 0xe7, 0x83, 0xa3, 0xd6, 0x35, 0xb6, 0xb8, 0x18, 0x1e, 0xf1,
+	0x85, 0x84, 0xb6, 0xc1, 0x18, 0xe2, 0x37, 0xfd, 0xc1, 0x68, 0x34, 0x38, 0x6a, 0xc9, 0x6c, 0xd9,
+	0x3f, 0x78, 0xdd, 0x1f, 0x9c, 0x9d, 0x0d, 0x8e, 0x5a, 0xca, 0xc3, 0x0b, 0x68, 0x0a, 0x2f, 0x6a,
+	0xd9, 0xcd, 0x0e, 0x40, 0x76, 0xf2, 0xe4, 0xf5, 0xcb, 0x96, 0xc4, 0x8c, 0xa3, 0xe3, 0x93, 0xe1,
+	0x30, 0xf7, 0x73, 0x34, 0x38, 0x3b, 0x79, 0x3b, 0xc0, 0xcc, 0x4f, 0xd9, 0xad, 0xda, 0xfb, 0xa3,
+	0x01, 0x37, 0xb8, 0xdf, 0x57, 0xc5, 0x3f, 0x30, 0x7a, 0x0a, 0xba, 0x93, 0x0d, 0x2f, 0xaa, 0xf1,
+	0xef, 0xdc, 0xad, 0x51, 0x1d, 0x25, 0xb1, 0x17, 0x4c, 0xdf, 0x3a, 0xb3, 0x39, 0x61, 0x67, 0xa7,
+	0xd9, 0x30, 0xa1, 0x8d, 0xc8, 0x4e, 0xcd, 0x33, 0x3a, 0x84, 0xeb, 0x54, 0x78, 0xe4, 0xd0, 0x7d,
+	0x01, 0xb1, 0xe2, 0xf5, 0xab, 0x7b, 0xd8, 0x97, 0x50, 0x1f, 0xae, 0xcf, 0x05, 0x4d, 0x5e, 0x11,
+	0xff, 0x83, 0xd5, 0x62, 0x5c, 0x8c, 0x65, 0x57, 0x42, 0x23, 0xd8, 0x8e, 0xc4, 0x89, 0xbd, 0x82,
+	0x89, 0x59, 0x9f, 0xbe, 0xaa, 0xcb, 0x7d, 0x09, 0x3d, 0x83, 0xa6, 0xeb, 0x04, 0x2e, 0x99, 0xfd,
+	0xb3, 0xe4, 0x0c, 0xa0, 0xf9, 0x79, 0x39, 0x5a, 0xe8, 0x9e, 0x00, 0xa8, 0x8f, 0x5c, 0x67, 0x77,
+	0xe5, 0x44, 0xec, 0x4b, 0xe8, 0x39, 0x34, 0x85, 0xfc, 0xa0, 0x7b, 0xab, 0x93, 0xb1, 0x36, 0xc3,
+	0xe8, 0x18, 0x9a, 0x53, 0x52, 0xfc, 0x02, 0x5d, 0xc1, 0x62, 0x93, 0x9c, 0x21, 0x0c, 0xdb, 0x33,
+	0xf1, 0x6f, 0x0a, 0x89, 0x85, 0x59, 0xf5, 0x47, 0xda, 0x31, 0xd7, 0x03, 0x32, 0x45, 0x1d, 0xc2,
+	0x2d, 0x21, 0xba, 0x17, 0x61, 0xfc, 0x35, 0xb9, 0xde, 0x18, 0xe5, 0x33, 0x30, 0xf2, 0x59, 0xa0,
+	0x2b, 0x87, 0xa1, 0xfe, 0x16, 0x8a, 0x9d, 0x74, 0x01, 0x3b, 0xe3, 0xd2, 0x03, 0x83, 0x44, 0x12,
+	0x2b, 0x1f, 0xac, 0xce, 0xb7, 0x1b, 0x10, 0xa9, 0xe3, 0xf1, 0x16, 0x27, 0xf2, 0xe8, 0xef, 0x01,
+	0x00, 0xfb, 0x2c, 0xf0, 0xa9, 0xc0, 0x0e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -197,9 +1170,17 @@ const _ = grpc.SupportPackageIsVersion6
 type OrderManagementClient interface {
 	AddOrder(ctx context.Context, in *Order, opts ...grpc.CallOption) (*wrappers.StringValue, error)
 	GetOrder(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (*Order, error)
-	SearchOrders(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (OrderManagement_SearchOrdersClient, error)
+	SearchOrders(ctx context.Context, in *SearchOrdersRequest, opts ...grpc.CallOption) (OrderManagement_SearchOrdersClient, error)
 	UpdateOrders(ctx context.Context, opts ...grpc.CallOption) (OrderManagement_UpdateOrdersClient, error)
 	ProcessOrders(ctx context.Context, opts ...grpc.CallOption) (OrderManagement_ProcessOrdersClient, error)
+	CancelOrder(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (*Order, error)
+	WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (OrderManagement_WatchOrdersClient, error)
+	UpdateOrder(ctx context.Context, in *UpdateOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	GetShipment(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (*CombinedShipment, error)
+	ListShipments(ctx context.Context, in *ListShipmentsRequest, opts ...grpc.CallOption) (*ListShipmentsResponse, error)
+	GetShipmentForOrder(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (*CombinedShipment, error)
+	AddOrders(ctx context.Context, opts ...grpc.CallOption) (OrderManagement_AddOrdersClient, error)
+	BatchGetOrders(ctx context.Context, in *BatchGetOrdersRequest, opts ...grpc.CallOption) (*BatchGetOrdersResponse, error)
 }
 
 type orderManagementClient struct {
@@ -228,7 +1209,7 @@ func (c *orderManagementClient) GetOrder(ctx context.Context, in *wrappers.Strin
 	return out, nil
 }
 
-func (c *orderManagementClient) SearchOrders(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (OrderManagement_SearchOrdersClient, error) {
+func (c *orderManagementClient) SearchOrders(ctx context.Context, in *SearchOrdersRequest, opts ...grpc.CallOption) (OrderManagement_SearchOrdersClient, error) {
 	stream, err := c.cc.NewStream(ctx, &_OrderManagement_serviceDesc.Streams[0], "/ecommerce.OrderManagement/searchOrders", opts...)
 	if err != nil {
 		return nil, err
@@ -271,7 +1252,7 @@ func (c *orderManagementClient) UpdateOrders(ctx context.Context, opts ...grpc.C
 
 type OrderManagement_UpdateOrdersClient interface {
 	Send(*Order) error
-	CloseAndRecv() (*wrappers.StringValue, error)
+	CloseAndRecv() (*UpdateOrdersResponse, error)
 	grpc.ClientStream
 }
 
@@ -283,11 +1264,11 @@ func (x *orderManagementUpdateOrdersClient) Send(m *Order) error {
 	return x.ClientStream.SendMsg(m)
 }
 
-func (x *orderManagementUpdateOrdersClient) CloseAndRecv() (*wrappers.StringValue, error) {
+func (x *orderManagementUpdateOrdersClient) CloseAndRecv() (*UpdateOrdersResponse, error) {
 	if err := x.ClientStream.CloseSend(); err != nil {
 		return nil, err
 	}
-	m := new(wrappers.StringValue)
+	m := new(UpdateOrdersResponse)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
@@ -305,7 +1286,7 @@ func (c *orderManagementClient) ProcessOrders(ctx context.Context, opts ...grpc.
 
 type OrderManagement_ProcessOrdersClient interface {
 	Send(*wrappers.StringValue) error
-	Recv() (*CombinedShipment, error)
+	Recv() (*ProcessOrdersResponse, error)
 	grpc.ClientStream
 }
 
@@ -317,21 +1298,149 @@ func (x *orderManagementProcessOrdersClient) Send(m *wrappers.StringValue) error
 	return x.ClientStream.SendMsg(m)
 }
 
-func (x *orderManagementProcessOrdersClient) Recv() (*CombinedShipment, error) {
-	m := new(CombinedShipment)
+func (x *orderManagementProcessOrdersClient) Recv() (*ProcessOrdersResponse, error) {
+	m := new(ProcessOrdersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *orderManagementClient) CancelOrder(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/cancelOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (OrderManagement_WatchOrdersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_OrderManagement_serviceDesc.Streams[3], "/ecommerce.OrderManagement/watchOrders", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orderManagementWatchOrdersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OrderManagement_WatchOrdersClient interface {
+	Recv() (*OrderEvent, error)
+	grpc.ClientStream
+}
+
+type orderManagementWatchOrdersClient struct {
+	grpc.ClientStream
+}
+
+func (x *orderManagementWatchOrdersClient) Recv() (*OrderEvent, error) {
+	m := new(OrderEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *orderManagementClient) UpdateOrder(ctx context.Context, in *UpdateOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/updateOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) GetShipment(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (*CombinedShipment, error) {
+	out := new(CombinedShipment)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/getShipment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) ListShipments(ctx context.Context, in *ListShipmentsRequest, opts ...grpc.CallOption) (*ListShipmentsResponse, error) {
+	out := new(ListShipmentsResponse)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/listShipments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) GetShipmentForOrder(ctx context.Context, in *wrappers.StringValue, opts ...grpc.CallOption) (*CombinedShipment, error) {
+	out := new(CombinedShipment)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/getShipmentForOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) AddOrders(ctx context.Context, opts ...grpc.CallOption) (OrderManagement_AddOrdersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_OrderManagement_serviceDesc.Streams[4], "/ecommerce.OrderManagement/addOrders", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orderManagementAddOrdersClient{stream}
+	return x, nil
+}
+
+type OrderManagement_AddOrdersClient interface {
+	Send(*Order) error
+	CloseAndRecv() (*AddOrdersResponse, error)
+	grpc.ClientStream
+}
+
+type orderManagementAddOrdersClient struct {
+	grpc.ClientStream
+}
+
+func (x *orderManagementAddOrdersClient) Send(m *Order) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *orderManagementAddOrdersClient) CloseAndRecv() (*AddOrdersResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AddOrdersResponse)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
+func (c *orderManagementClient) BatchGetOrders(ctx context.Context, in *BatchGetOrdersRequest, opts ...grpc.CallOption) (*BatchGetOrdersResponse, error) {
+	out := new(BatchGetOrdersResponse)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/batchGetOrders", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // OrderManagementServer is the server API for OrderManagement service.
 type OrderManagementServer interface {
 	AddOrder(context.Context, *Order) (*wrappers.StringValue, error)
 	GetOrder(context.Context, *wrappers.StringValue) (*Order, error)
-	SearchOrders(*wrappers.StringValue, OrderManagement_SearchOrdersServer) error
+	SearchOrders(*SearchOrdersRequest, OrderManagement_SearchOrdersServer) error
 	UpdateOrders(OrderManagement_UpdateOrdersServer) error
 	ProcessOrders(OrderManagement_ProcessOrdersServer) error
+	CancelOrder(context.Context, *wrappers.StringValue) (*Order, error)
+	WatchOrders(*WatchOrdersRequest, OrderManagement_WatchOrdersServer) error
+	UpdateOrder(context.Context, *UpdateOrderRequest) (*Order, error)
+	GetShipment(context.Context, *wrappers.StringValue) (*CombinedShipment, error)
+	ListShipments(context.Context, *ListShipmentsRequest) (*ListShipmentsResponse, error)
+	GetShipmentForOrder(context.Context, *wrappers.StringValue) (*CombinedShipment, error)
+	AddOrders(OrderManagement_AddOrdersServer) error
+	BatchGetOrders(context.Context, *BatchGetOrdersRequest) (*BatchGetOrdersResponse, error)
 }
 
 // UnimplementedOrderManagementServer can be embedded to have forward compatible implementations.
@@ -339,19 +1448,43 @@ type UnimplementedOrderManagementServer struct {
 }
 
 func (*UnimplementedOrderManagementServer) AddOrder(ctx context.Context, req *Order) (*wrappers.StringValue, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddOrder not implemented")
+	return nil, status1.Errorf(codes.Unimplemented, "method AddOrder not implemented")
 }
 func (*UnimplementedOrderManagementServer) GetOrder(ctx context.Context, req *wrappers.StringValue) (*Order, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+	return nil, status1.Errorf(codes.Unimplemented, "method GetOrder not implemented")
 }
-func (*UnimplementedOrderManagementServer) SearchOrders(req *wrappers.StringValue, srv OrderManagement_SearchOrdersServer) error {
-	return status.Errorf(codes.Unimplemented, "method SearchOrders not implemented")
+func (*UnimplementedOrderManagementServer) SearchOrders(req *SearchOrdersRequest, srv OrderManagement_SearchOrdersServer) error {
+	return status1.Errorf(codes.Unimplemented, "method SearchOrders not implemented")
 }
 func (*UnimplementedOrderManagementServer) UpdateOrders(srv OrderManagement_UpdateOrdersServer) error {
-	return status.Errorf(codes.Unimplemented, "method UpdateOrders not implemented")
+	return status1.Errorf(codes.Unimplemented, "method UpdateOrders not implemented")
 }
 func (*UnimplementedOrderManagementServer) ProcessOrders(srv OrderManagement_ProcessOrdersServer) error {
-	return status.Errorf(codes.Unimplemented, "method ProcessOrders not implemented")
+	return status1.Errorf(codes.Unimplemented, "method ProcessOrders not implemented")
+}
+func (*UnimplementedOrderManagementServer) CancelOrder(ctx context.Context, req *wrappers.StringValue) (*Order, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
+}
+func (*UnimplementedOrderManagementServer) WatchOrders(req *WatchOrdersRequest, srv OrderManagement_WatchOrdersServer) error {
+	return status1.Errorf(codes.Unimplemented, "method WatchOrders not implemented")
+}
+func (*UnimplementedOrderManagementServer) UpdateOrder(ctx context.Context, req *UpdateOrderRequest) (*Order, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method UpdateOrder not implemented")
+}
+func (*UnimplementedOrderManagementServer) GetShipment(ctx context.Context, req *wrappers.StringValue) (*CombinedShipment, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method GetShipment not implemented")
+}
+func (*UnimplementedOrderManagementServer) ListShipments(ctx context.Context, req *ListShipmentsRequest) (*ListShipmentsResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method ListShipments not implemented")
+}
+func (*UnimplementedOrderManagementServer) GetShipmentForOrder(ctx context.Context, req *wrappers.StringValue) (*CombinedShipment, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method GetShipmentForOrder not implemented")
+}
+func (*UnimplementedOrderManagementServer) AddOrders(srv OrderManagement_AddOrdersServer) error {
+	return status1.Errorf(codes.Unimplemented, "method AddOrders not implemented")
+}
+func (*UnimplementedOrderManagementServer) BatchGetOrders(ctx context.Context, req *BatchGetOrdersRequest) (*BatchGetOrdersResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method BatchGetOrders not implemented")
 }
 
 func RegisterOrderManagementServer(s *grpc.Server, srv OrderManagementServer) {
@@ -395,7 +1528,7 @@ func _OrderManagement_GetOrder_Handler(srv interface{}, ctx context.Context, dec
 }
 
 func _OrderManagement_SearchOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(wrappers.StringValue)
+	m := new(SearchOrdersRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
@@ -420,7 +1553,7 @@ func _OrderManagement_UpdateOrders_Handler(srv interface{}, stream grpc.ServerSt
 }
 
 type OrderManagement_UpdateOrdersServer interface {
-	SendAndClose(*wrappers.StringValue) error
+	SendAndClose(*UpdateOrdersResponse) error
 	Recv() (*Order, error)
 	grpc.ServerStream
 }
@@ -429,7 +1562,7 @@ type orderManagementUpdateOrdersServer struct {
 	grpc.ServerStream
 }
 
-func (x *orderManagementUpdateOrdersServer) SendAndClose(m *wrappers.StringValue) error {
+func (x *orderManagementUpdateOrdersServer) SendAndClose(m *UpdateOrdersResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
@@ -446,7 +1579,7 @@ func _OrderManagement_ProcessOrders_Handler(srv interface{}, stream grpc.ServerS
 }
 
 type OrderManagement_ProcessOrdersServer interface {
-	Send(*CombinedShipment) error
+	Send(*ProcessOrdersResponse) error
 	Recv() (*wrappers.StringValue, error)
 	grpc.ServerStream
 }
@@ -455,7 +1588,7 @@ type orderManagementProcessOrdersServer struct {
 	grpc.ServerStream
 }
 
-func (x *orderManagementProcessOrdersServer) Send(m *CombinedShipment) error {
+func (x *orderManagementProcessOrdersServer) Send(m *ProcessOrdersResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
@@ -467,6 +1600,161 @@ func (x *orderManagementProcessOrdersServer) Recv() (*wrappers.StringValue, erro
 	return m, nil
 }
 
+func _OrderManagement_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).CancelOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/CancelOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).CancelOrder(ctx, req.(*wrappers.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_WatchOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderManagementServer).WatchOrders(m, &orderManagementWatchOrdersServer{stream})
+}
+
+type OrderManagement_WatchOrdersServer interface {
+	Send(*OrderEvent) error
+	grpc.ServerStream
+}
+
+type orderManagementWatchOrdersServer struct {
+	grpc.ServerStream
+}
+
+func (x *orderManagementWatchOrdersServer) Send(m *OrderEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _OrderManagement_UpdateOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).UpdateOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/UpdateOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).UpdateOrder(ctx, req.(*UpdateOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_GetShipment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).GetShipment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/GetShipment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).GetShipment(ctx, req.(*wrappers.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_ListShipments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListShipmentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).ListShipments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/ListShipments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).ListShipments(ctx, req.(*ListShipmentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_GetShipmentForOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).GetShipmentForOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/GetShipmentForOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).GetShipmentForOrder(ctx, req.(*wrappers.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_AddOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OrderManagementServer).AddOrders(&orderManagementAddOrdersServer{stream})
+}
+
+type OrderManagement_AddOrdersServer interface {
+	SendAndClose(*AddOrdersResponse) error
+	Recv() (*Order, error)
+	grpc.ServerStream
+}
+
+type orderManagementAddOrdersServer struct {
+	grpc.ServerStream
+}
+
+func (x *orderManagementAddOrdersServer) SendAndClose(m *AddOrdersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *orderManagementAddOrdersServer) Recv() (*Order, error) {
+	m := new(Order)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _OrderManagement_BatchGetOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).BatchGetOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/BatchGetOrders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).BatchGetOrders(ctx, req.(*BatchGetOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _OrderManagement_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ecommerce.OrderManagement",
 	HandlerType: (*OrderManagementServer)(nil),
@@ -479,6 +1767,30 @@ var _OrderManagement_serviceDesc = grpc.ServiceDesc{
 			MethodName: "getOrder",
 			Handler:    _OrderManagement_GetOrder_Handler,
 		},
+		{
+			MethodName: "cancelOrder",
+			Handler:    _OrderManagement_CancelOrder_Handler,
+		},
+		{
+			MethodName: "updateOrder",
+			Handler:    _OrderManagement_UpdateOrder_Handler,
+		},
+		{
+			MethodName: "getShipment",
+			Handler:    _OrderManagement_GetShipment_Handler,
+		},
+		{
+			MethodName: "listShipments",
+			Handler:    _OrderManagement_ListShipments_Handler,
+		},
+		{
+			MethodName: "getShipmentForOrder",
+			Handler:    _OrderManagement_GetShipmentForOrder_Handler,
+		},
+		{
+			MethodName: "batchGetOrders",
+			Handler:    _OrderManagement_BatchGetOrders_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -497,6 +1809,16 @@ var _OrderManagement_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "watchOrders",
+			Handler:       _OrderManagement_WatchOrders_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "addOrders",
+			Handler:       _OrderManagement_AddOrders_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "order_management.proto",
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// The default client pipeline, the tracer starts the span of the call and sends the trace context,
+// then the call logger logs the call with the request ID.
+const defaultClientInterceptors = "tracing,logging"
+
+// clientInterceptor is one concern of the client pipeline, e.g. the tracing or the call logging.
+type clientInterceptor struct {
+	name   string
+	unary  grpc.UnaryClientInterceptor  // Nil if the concern doesn't apply to the unary calls.
+	stream grpc.StreamClientInterceptor // Nil if the concern doesn't apply to the stream calls.
+}
+
+// Parse the comma-separated interceptor names, e.g. "tracing,logging".
+func parseInterceptorNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Assemble the dial options of the client pipeline from the available interceptors by the names in order,
+// the first one is the outermost. Every name must be available and used once.
+func newClientPipeline(available []clientInterceptor, names []string) ([]grpc.DialOption, error) {
+	byName := make(map[string]clientInterceptor)
+	for _, interceptor := range available {
+		byName[interceptor.name] = interceptor
+	}
+	used := make(map[string]bool)
+	var unary []grpc.UnaryClientInterceptor
+	var stream []grpc.StreamClientInterceptor
+	for _, name := range names {
+		interceptor, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown interceptor %q, must be one of %s", name, clientInterceptorNames(available))
+		}
+		if used[name] {
+			return nil, fmt.Errorf("interceptor %q is used more than once", name)
+		}
+		used[name] = true
+		if interceptor.unary != nil {
+			unary = append(unary, interceptor.unary)
+		}
+		if interceptor.stream != nil {
+			stream = append(stream, interceptor.stream)
+		}
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary...),   // Register unary interceptors.
+		grpc.WithChainStreamInterceptor(stream...), // Register stream interceptors.
+	}, nil
+}
+
+func clientInterceptorNames(interceptors []clientInterceptor) string {
+	names := make([]string, len(interceptors))
+	for i, interceptor := range interceptors {
+		names[i] = interceptor.name
+	}
+	return strings.Join(names, ", ")
+}
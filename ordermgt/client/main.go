@@ -2,15 +2,24 @@ package main
 
 import (
 	"context"
+	"flag"
+	"github.com/golang/protobuf/ptypes"
 	wrapper "github.com/golang/protobuf/ptypes/wrappers"
 	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	hwpb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	"grpc-up-and-running/common/calllog"
+	"grpc-up-and-running/common/tracing"
 	"io"
 	"log"
+	"os"
 	pb "ordergmt/client/ecommerce"
+	"strings"
 	"time"
 )
 
@@ -18,11 +27,45 @@ const (
 	address = "localhost:50051"
 )
 
+var (
+	logFormat = flag.String("log-format", "logfmt", "The format of the call logs: json or logfmt")
+	logLevelName = flag.String("log-level", "info", "The min level of the call logs: debug (with the payloads), info, warn or error")
+	logRedact = flag.String("log-redact", "", "The comma-separated payload fields to redact in the call logs, e.g. destination,price")
+	tracePath = flag.String("trace-path", "", "The path of the file the spans are exported to in the OTLP JSON format, empty disables the export")
+	interceptors = flag.String("interceptors", defaultClientInterceptors, "The comma-separated interceptors of the calls in order, the first one is the outermost: tracing and logging")
+)
+
 func main() {
+	flag.Parse()
+
+	// Log every call, with the payloads of the messages at the debug level.
+	callLog, err := newClientCallLogger()
+	if err != nil {
+		log.Fatalf("invalid call log options: %v", err)
+	}
+
+	// Trace every call, the trace context is sent to the server in the traceparent metadata.
+	tracer := newTracer(nil)
+	if *tracePath != "" {
+		exporter, err := tracing.NewFileSpanExporter(*tracePath, "ordermgt-client")
+		if err != nil {
+			log.Fatalf("failed to create the span exporter: %v", err)
+		}
+		defer exporter.Close()
+		tracer = newTracer(exporter)
+	}
+
+	// Each concern is its own interceptor, the pipeline is assembled in the order of the -interceptors flag.
+	pipeline, err := newClientPipeline([]clientInterceptor{
+		{name: "tracing", unary: tracer.unaryClientInterceptor, stream: tracer.streamClientInterceptor},
+		{name: "logging", unary: callLog.unaryClientInterceptor, stream: callLog.streamClientInterceptor},
+	}, parseInterceptorNames(*interceptors))
+	if err != nil {
+		log.Fatalf("invalid interceptors: %v", err)
+	}
+
 	// Setting up a connection to the server.
-	conn, err := grpc.Dial(address, grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(orderUnaryClientInterceptor),    // Register unary interceptor.
-		grpc.WithStreamInterceptor(clientStreamInterceptor))       // Register stream interceptor.
+	conn, err := grpc.Dial(address, append([]grpc.DialOption{grpc.WithInsecure()}, pipeline...)...)
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
@@ -32,8 +75,13 @@ func main() {
 	orderMgtClient := pb.NewOrderManagementClient(conn)
 	helloClient    := hwpb.NewGreeterClient(conn)
 
+	// All the calls below are in one trace, under the root span of the client.
+	traceCtx, rootSpan := tracer.StartSpan(context.Background(), "ordermgt-client", tracing.SpanKindInternal)
+	defer rootSpan.Finish(nil)
+	log.Printf("Trace context : %s", rootSpan.Context)
+
 	// Initialize context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second * 5)
+	ctx, cancel := context.WithTimeout(traceCtx, time.Second * 5)
 
 	// Initialize context with deadline
 	//clientDeadline := time.Now().Add(time.Duration(2 * time.Second))
@@ -41,18 +89,36 @@ func main() {
 
 	defer cancel()
 
+	// =========================================
+	// Watch Orders : Server streaming scenario
+	// =========================================
+	// Log all the order changes made by the calls below in the background.
+	watchCtx, cancelWatch := context.WithCancel(traceCtx)
+	defer cancelWatch()
+	watchStream, err := orderMgtClient.WatchOrders(watchCtx, &pb.WatchOrdersRequest{})
+	if err != nil {
+		log.Fatalf("%v.WatchOrders(_) = _, %v", orderMgtClient, err)
+	}
+	go watchOrderEvents(watchStream)
+
 	// =========================================
 	// Add Order
 	// =========================================
 	// Case 1: Add an order with valid ID
-	order1 := pb.Order{Id: "101", Items: []string{"iPhone XS", "Mac Book Pro"}, Destination: "San Jose, CA", Price: 2300.00}
-	res, _ := orderMgtClient.AddOrder(ctx, &order1)
+	// The idempotency key makes the retry get the original result instead of replacing the order again.
+	order1 := pb.Order{Id: "101", Items: []string{"iPhone XS", "Mac Book Pro"}, Destination: "San Jose, CA", Price: amount.New("USD", 2300, 0)}
+	addCtx := metadata.AppendToOutgoingContext(ctx, "idempotency-key", "add-order-101")
+	res, _ := orderMgtClient.AddOrder(addCtx, &order1)
 	if res != nil {
 		log.Print("AddOrder Response -> ", res.Value)
 	}
+	res, _ = orderMgtClient.AddOrder(addCtx, &order1)
+	if res != nil {
+		log.Print("AddOrder Retry Response -> ", res.Value)
+	}
 
 	// Case 2: Add an order with invalid ID
-	order2 := pb.Order{Id: "-1", Items:[]string{"iPhone XS", "Mac Book Pro"}, Destination:"San Jose, CA", Price:2300.00}
+	order2 := pb.Order{Id: "-1", Items:[]string{"iPhone XS", "Mac Book Pro"}, Destination:"San Jose, CA", Price: amount.New("USD", 2300, 0)}
 	res, addOrderError := orderMgtClient.AddOrder(ctx, &order2)
 
 	if addOrderError != nil {
@@ -62,8 +128,11 @@ func main() {
 			errorStatus := status.Convert(addOrderError)
 			for _, d := range errorStatus.Details() {
 				switch info := d.(type) {
-				case *epb.BadRequest_FieldViolation:
-					log.Printf("Request Field Invalid: %s", info)
+				case *epb.BadRequest:
+					// All the invalid fields are reported in one BadRequest.
+					for _, violation := range info.FieldViolations {
+						log.Printf("Request Field Invalid: %s", violation)
+					}
 				default:
 					log.Printf("Unexpected error type: %s", info)
 				}
@@ -75,6 +144,45 @@ func main() {
 		log.Print("AddOrder Response -> ", res.Value)
 	}
 
+	// =========================================
+	// Add Orders : Client streaming scenario
+	// =========================================
+	// Import multiple orders in one call, the invalid order 112 doesn't stop the others.
+	addStream, err := orderMgtClient.AddOrders(ctx)
+	if err != nil {
+		log.Fatalf("%v.AddOrders(_) = _, %v", orderMgtClient, err)
+	}
+	for _, order := range []*pb.Order{
+		{Id: "110", Items: []string{"Google Pixel Buds"}, Destination: "Mountain View, CA", Price: amount.New("USD", 179, 0)},
+		{Id: "111", Items: []string{"Apple AirPods"}, Destination: "1 Infinite Loop, Cupertino, CA 95014", Price: amount.New("USD", 159, 0)},
+		{Id: "112", Items: []string{"Amazon Echo Dot"}, Destination: "Seattle", Price: amount.New("USD", 49, 990000000)},
+	} {
+		if err := addStream.Send(order); err != nil {
+			log.Fatalf("%v.Send(%v) = %v", addStream, order, err)
+		}
+	}
+	addRes, err := addStream.CloseAndRecv()
+	if err != nil {
+		log.Fatalf("%v.CloseAndRecv() got error %v, want %v", addStream, err, nil)
+	}
+	for _, result := range addRes.Results {
+		resultStatus := status.FromProto(result.Status)
+		log.Printf("Add Orders Res : %s - %s %s", result.OrderId, resultStatus.Code(), resultStatus.Message())
+	}
+
+	// =========================================
+	// Batch Get Orders
+	// =========================================
+	batchRes, err := orderMgtClient.BatchGetOrders(ctx, &pb.BatchGetOrdersRequest{OrderIds: []string{"110", "111", "112"}})
+	if err != nil {
+		log.Printf("BatchGetOrders error : %v", err)
+	} else {
+		for _, order := range batchRes.Orders {
+			log.Print("BatchGetOrders Found -> ", order.Id)
+		}
+		log.Print("BatchGetOrders Missing -> ", batchRes.MissingIds)
+	}
+
 	// =========================================
 	// Get Order
 	// =========================================
@@ -84,25 +192,46 @@ func main() {
 	// =========================================
 	// Search Order : Server streaming scenario
 	// =========================================
-	searchStream, 	_ := orderMgtClient.SearchOrders(ctx, &wrapper.StringValue{Value: "Google"})
+	// Fetch the matching orders 1 at a time, the next page is resumed by the page token in the trailer.
+	searchReq := &pb.SearchOrdersRequest{Items: "Google", Destination: "Mountain View", MaxResults: 1}
 	for {
-		searchOrder, err := searchStream.Recv()
-		if err == io.EOF {
-			log.Print("EOF")
+		searchStream, err := orderMgtClient.SearchOrders(ctx, searchReq)
+		if err != nil {
+			log.Printf("SearchOrders error : %v", err)
 			break
 		}
-
-		if err == nil {
+		for {
+			searchOrder, err := searchStream.Recv()
+			if err == io.EOF {
+				log.Print("EOF")
+				break
+			}
+			if err != nil {
+				log.Printf("SearchOrders error : %v", err)
+				break
+			}
 			log.Print("Search Result : ", searchOrder)
 		}
+		pageTokens := searchStream.Trailer().Get("next-page-token")
+		if len(pageTokens) == 0 {
+			break
+		}
+		searchReq.PageToken = pageTokens[0]
 	}
 
 	// =========================================
 	// Update Orders : Client streaming scenario
 	// =========================================
-	updOrder1 := pb.Order{Id: "102", Items:[]string{"Google Pixel 3A", "Google Pixel Book"}, Destination:"Mountain View, CA", Price:1100.00}
-	updOrder2 := pb.Order{Id: "103", Items:[]string{"Apple Watch S4", "Mac Book Pro", "iPad Pro"}, Destination:"San Jose, CA", Price:2800.00}
-	updOrder3 := pb.Order{Id: "104", Items:[]string{"Google Home Mini", "Google Nest Hub", "iPad Mini"}, Destination:"Mountain View, CA", Price:2200.00}
+	updOrder1 := pb.Order{Id: "102", Items:[]string{"Google Pixel 3A", "Google Pixel Book"}, Destination:"Mountain View, CA", Price: amount.New("USD", 1100, 0)}
+	updOrder2 := pb.Order{Id: "103", Items:[]string{"Apple Watch S4", "Mac Book Pro", "iPad Pro"}, Destination:"San Jose, CA", Price: amount.New("USD", 2800, 0)}
+	updOrder3 := pb.Order{Id: "104", Items:[]string{"Google Home Mini", "Google Nest Hub", "iPad Mini"}, Destination:"Mountain View, CA", Price: amount.New("USD", 2200, 0)}
+
+	// Update order 1 only if it hasn't been changed since it was read.
+	// The stale update of the same version conflicts with it and is aborted.
+	if readOrder1, err := orderMgtClient.GetOrder(ctx, &wrapper.StringValue{Value: "102"}); err == nil {
+		updOrder1.Version = readOrder1.Version
+	}
+	staleOrder1 := pb.Order{Id: "102", Items:[]string{"Google Pixel 3A"}, Destination:"Mountain View, CA", Price: amount.New("USD", 1800, 0), Version:updOrder1.Version}
 
 	updateStream, err := orderMgtClient.UpdateOrders(ctx)
 
@@ -125,19 +254,56 @@ func main() {
 		log.Fatalf("%v.Send(%v) = %v", updateStream, updOrder3, err)
 	}
 
+	// Updating order 1 again with the stale version
+	if err := updateStream.Send(&staleOrder1); err != nil {
+		log.Fatalf("%v.Send(%v) = %v", updateStream, staleOrder1, err)
+	}
+
 	updateRes, err := updateStream.CloseAndRecv()
 	if err != nil {
 		log.Fatalf("%v.CloseAndRecv() got error %v, want %v", updateStream, err, nil)
 	}
-	log.Printf("Update Orders Res : %s", updateRes)
+	for _, result := range updateRes.Results {
+		// Each order has its own outcome, a failed order doesn't affect the other orders.
+		resultStatus := status.FromProto(result.Status)
+		if resultStatus.Code() == codes.OK {
+			log.Printf("Update Orders Res : %s - updated to version %d", result.OrderId, result.Version)
+			continue
+		}
+		log.Printf("Update Orders Res : %s - %s : %s (current version %d)", result.OrderId, resultStatus.Code(), resultStatus.Message(), result.Version)
+		for _, d := range resultStatus.Details() {
+			log.Printf("Update failure detail : %v", d)
+		}
+	}
+
+	// =========================================
+	// Update Order : Partial update by field mask
+	// =========================================
+	// Only change the destination of order 103, the other fields are kept as they are on the server.
+	patchedOrder, err := orderMgtClient.UpdateOrder(ctx, &pb.UpdateOrderRequest{
+		Order:      &pb.Order{Id: "103", Destination: "Sunnyvale, CA"},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"destination"}},
+	})
+	if err != nil {
+		log.Printf("UpdateOrder error : %v", err)
+	} else {
+		log.Print("UpdateOrder Response -> ", patchedOrder)
+	}
 
 	// =========================================
 	// Process Order : Bi-di streaming scenario
 	// =========================================
-	streamProcOrder, err := orderMgtClient.ProcessOrders(ctx)
+	// Negotiate the batch parameters through the metadata:
+	// Flush the combined shipments every 2 orders, or after 500 ms without new order.
+	// Combine the orders to the same city, whatever the street addresses are.
+	procCtx := metadata.AppendToOutgoingContext(ctx, "batch-size", "2", "batch-wait-ms", "500", "grouping", "city")
+	streamProcOrder, err := orderMgtClient.ProcessOrders(procCtx)
 	if err != nil {
 		log.Fatalf("%v.ProcessOrders(_) = _, %v", orderMgtClient, err)
 	}
+	if header, err := streamProcOrder.Header(); err == nil {
+		log.Printf("Batch parameters : size = %v, wait (ms) = %v, grouping = %v", header.Get("batch-size"), header.Get("batch-wait-ms"), header.Get("grouping"))
+	}
 
 	if err := streamProcOrder.Send(&wrapper.StringValue{Value:"102"}); err != nil {
 		log.Fatalf("%v.Send(%v) = %v", orderMgtClient, "102", err)
@@ -151,80 +317,155 @@ func main() {
 		log.Fatalf("%v.Send(%v) = %v", orderMgtClient, "104", err)
 	}
 
-	channel := make(chan struct{})
+	channel := make(chan []string)
 	go asncClientBidirectionalRPC(streamProcOrder, channel)
 	time.Sleep(time.Millisecond * 1000)
 
 	if err := streamProcOrder.Send(&wrapper.StringValue{Value:"101"}); err != nil {
 		log.Fatalf("%v.Send(%v) = %v", orderMgtClient, "101", err)
 	}
+
+	// Order 999 doesn't exist, it will be rejected by a ProcessingError in the response stream.
+	if err := streamProcOrder.Send(&wrapper.StringValue{Value:"999"}); err != nil {
+		log.Fatalf("%v.Send(%v) = %v", orderMgtClient, "999", err)
+	}
 	if err := streamProcOrder.CloseSend(); err != nil {
 		log.Fatal(err)
 	}
-	<- channel
+	shipmentIds := <- channel
 
 	// =========================================
-	// SayHello : Call another service running on the same server
+	// Get Shipment
 	// =========================================
-	hwCtx, cancel := context.WithTimeout(context.Background(), time.Second * 5)
-	helloResponse, err := helloClient.SayHello(hwCtx, &hwpb.HelloRequest{Name: "gRPC Up and Running!"})
-	log.Print("Hello world response: ", helloResponse.Message)
-}
+	// The combined shipments can be looked up by the IDs after being sent back.
+	for _, shipmentId := range shipmentIds {
+		shipment, err := orderMgtClient.GetShipment(ctx, &wrapper.StringValue{Value: shipmentId})
+		if err != nil {
+			log.Printf("GetShipment error : %v", err)
+			continue
+		}
+		log.Printf("GetShipment Response -> %s : %d orders to %s, created at %v", shipment.Id, shipment.OrderCount, shipment.Destination, ptypes.TimestampString(shipment.CreateTime))
+	}
 
-func asncClientBidirectionalRPC(streamProcOrder pb.OrderManagement_ProcessOrdersClient, c chan struct{}) {
+	// =========================================
+	// List Shipments
+	// =========================================
+	// List the shipments to San Jose page by page.
+	listReq := &pb.ListShipmentsRequest{Destination: "San Jose", PageSize: 1}
 	for {
-		combinedShipment, errProcOrder := streamProcOrder.Recv()
-		if errProcOrder == io.EOF {
+		listRes, err := orderMgtClient.ListShipments(ctx, listReq)
+		if err != nil {
+			log.Printf("ListShipments error : %v", err)
 			break
 		}
-		log.Printf("Combined shipment : ", combinedShipment.OrdersList)
+		for _, shipment := range listRes.Shipments {
+			log.Printf("ListShipments Response -> %s : %d orders to %s", shipment.Id, shipment.OrderCount, shipment.Destination)
+		}
+		if listRes.NextPageToken == "" {
+			break
+		}
+		listReq.PageToken = listRes.NextPageToken
 	}
-	<-c
-}
-
-// Unary Interceptor (client-side)
-func orderUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-	// Pre-processing phase
-	log.Println("Method : " + method)
 
-	// Invoking the remote method
-	err := invoker(ctx, method, req, reply, cc, opts...)
-
-	// Post-processing phase
-	log.Println(reply)
-
-	return err
-}
+	// =========================================
+	// Get Shipment For Order
+	// =========================================
+	// Find the shipment which order 101 was shipped in.
+	shipment, err := orderMgtClient.GetShipmentForOrder(ctx, &wrapper.StringValue{Value: "101"})
+	if err != nil {
+		log.Printf("GetShipmentForOrder error : %v", err)
+	} else {
+		log.Printf("GetShipmentForOrder Response -> order 101 is in %s", shipment.Id)
+	}
 
-// wrappedStream wraps grpc.ClientStream and intercepts the RecvMsg and SendMsg method call.
-type wrappedStream struct {
-	grpc.ClientStream
-}
+	// =========================================
+	// Cancel Order
+	// =========================================
+	// Case 1: Cancel an order which hasn't been processed
+	cancelledOrder, err := orderMgtClient.CancelOrder(ctx, &wrapper.StringValue{Value: "105"})
+	if err != nil {
+		log.Printf("CancelOrder error : %v", err)
+	} else {
+		log.Print("CancelOrder Response -> ", cancelledOrder)
+	}
 
-func (w *wrappedStream) RecvMsg(m interface{}) error {
-	log.Printf("====== [Client Stream Interceptor] Receive a message (Type: %T) at %v", m, time.Now().Format(time.RFC3339))
-	return w.ClientStream.RecvMsg(m)
-}
+	// Case 2: Cancel an order which has been shipped
+	_, cancelOrderError := orderMgtClient.CancelOrder(ctx, &wrapper.StringValue{Value: "102"})
+	if status.Code(cancelOrderError) == codes.FailedPrecondition {
+		errorStatus := status.Convert(cancelOrderError)
+		log.Printf("Failed Precondition Error : %s", errorStatus.Message())
+		for _, d := range errorStatus.Details() {
+			switch info := d.(type) {
+			case *epb.PreconditionFailure:
+				log.Printf("Precondition Failure: %s", info)
+			default:
+				log.Printf("Unexpected error type: %s", info)
+			}
+		}
+	} else if cancelOrderError != nil {
+		log.Printf("Unhandled error : %s ", status.Code(cancelOrderError))
+	}
 
-func (w *wrappedStream) SendMsg(m interface{}) error {
-	log.Printf("====== [Client Stream Interceptor] Send a message (Type: %T) at %v", m, time.Now().Format(time.RFC3339))
-	return w.ClientStream.SendMsg(m)
+	// =========================================
+	// SayHello : Call another service running on the same server
+	// =========================================
+	hwCtx, cancel := context.WithTimeout(traceCtx, time.Second * 5)
+	helloResponse, err := helloClient.SayHello(hwCtx, &hwpb.HelloRequest{Name: "gRPC Up and Running!"})
+	log.Print("Hello world response: ", helloResponse.Message)
 }
 
-// Creating an instance of the new wrapper stream.
-func newWrappedStream(s grpc.ClientStream) grpc.ClientStream {
-	return &wrappedStream{s}
+func asncClientBidirectionalRPC(streamProcOrder pb.OrderManagement_ProcessOrdersClient, c chan []string) {
+	var shipmentIds []string
+	for {
+		procRes, errProcOrder := streamProcOrder.Recv()
+		if errProcOrder == io.EOF {
+			break
+		}
+		if errProcOrder != nil {
+			log.Printf("Process orders error : %v", errProcOrder)
+			break
+		}
+		switch result := procRes.Result.(type) {
+		case *pb.ProcessOrdersResponse_Shipment:
+			shipment := result.Shipment
+			log.Printf("Combined shipment %s (%s) to %s : %d orders, %d items, total %v", shipment.Id, shipment.Status, shipment.Destination, shipment.OrderCount, shipment.ItemCount, shipment.TotalPrice)
+			shipmentIds = append(shipmentIds, shipment.Id)
+		case *pb.ProcessOrdersResponse_Error:
+			// The order is rejected, but the stream is still alive for the other orders.
+			errorStatus := status.FromProto(result.Error.Status)
+			log.Printf("Order rejected : %s - %s : %s", result.Error.OrderId, errorStatus.Code(), errorStatus.Message())
+			for _, d := range errorStatus.Details() {
+				log.Printf("Rejection detail : %v", d)
+			}
+		}
+	}
+	c <- shipmentIds
 }
 
-// Streaming interceptor implementation.
-func clientStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-	// Pre-processing phase
-	log.Println("======= [Client Interceptor] ", method)
-
-	// Invoking the Streamer to complete the execution of RPC invocation
-	s, err := streamer(ctx, desc, cc, method, opts...)
+// Create the call logger writing to stderr by the flags.
+func newClientCallLogger() (*callLogger, error) {
+	level, err := calllog.ParseLevel(*logLevelName)
 	if err != nil {
 		return nil, err
 	}
-	return newWrappedStream(s), nil
-}
\ No newline at end of file
+	var redact []string
+	for _, field := range strings.Split(*logRedact, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			redact = append(redact, field)
+		}
+	}
+	return newCallLogger(os.Stderr, calllog.Config{Format: *logFormat, Level: level, Redact: redact})
+}
+
+// Log the order events until the watch is cancelled.
+// The resume token of the last event can be used for resuming the watch after reconnecting.
+func watchOrderEvents(watchStream pb.OrderManagement_WatchOrdersClient) {
+	for {
+		event, err := watchStream.Recv()
+		if err != nil {
+			log.Printf("Watch orders stopped : %v", err)
+			return
+		}
+		log.Printf("Order event : %s %s (resume token: %s)", event.Type, event.After.GetId(), event.ResumeToken)
+	}
+}
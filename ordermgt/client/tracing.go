@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"grpc-up-and-running/common/tracing"
+)
+
+// tracer adds the client interceptors to the tracer of the common module.
+type tracer struct {
+	*tracing.Tracer
+}
+
+// Create a tracer exporting the spans to exporter, or discarding them if exporter is nil.
+func newTracer(exporter tracing.SpanExporter) *tracer {
+	return &tracer{tracing.NewTracer(exporter)}
+}
+
+// Send the trace context of the span in the traceparent metadata, replacing the one already in the outgoing metadata.
+func contextWithTraceparent(ctx context.Context, s *tracing.Span) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(tracing.TraceparentKey, s.Context.String())
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// Unary interceptor creating a client span per call, the trace context is sent to the server in the traceparent metadata.
+func (t *tracer) unaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	name, attributes := tracing.RpcSpan(method)
+	ctx, s := t.StartSpan(ctx, name, tracing.SpanKindClient, attributes...)
+	err := invoker(contextWithTraceparent(ctx, s), method, req, reply, cc, opts...)
+	tracing.FinishRpcSpan(s, err)
+	return err
+}
+
+// Stream interceptor creating a client span per call, and a child span per message sent and received.
+// The span of the call ends when the stream ends, i.e. the response stream is drained or fails.
+func (t *tracer) streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	name, attributes := tracing.RpcSpan(method)
+	ctx, s := t.StartSpan(ctx, name, tracing.SpanKindClient, attributes...)
+	cs, err := streamer(contextWithTraceparent(ctx, s), desc, cc, method, opts...)
+	if err != nil {
+		tracing.FinishRpcSpan(s, err)
+		return nil, err
+	}
+	return &tracingClientStream{ClientStream: cs, ctx: ctx, tracer: t, desc: desc, name: name, span: s}, nil
+}
+
+// tracingClientStream records the messages of the stream as the child spans of the call.
+type tracingClientStream struct {
+	grpc.ClientStream
+	ctx      context.Context
+	tracer   *tracer
+	desc     *grpc.StreamDesc
+	name     string
+	span     *tracing.Span
+	received int64 // Updated atomically, the messages may be sent and received in different goroutines.
+	sent     int64
+	once     sync.Once
+}
+
+func (s *tracingClientStream) SendMsg(m interface{}) error {
+	start := s.tracer.Now()
+	err := s.ClientStream.SendMsg(m)
+	s.tracer.MessageSpan(s.ctx, s.name+"/send", "SENT", atomic.AddInt64(&s.sent, 1), start, err)
+	return err
+}
+
+func (s *tracingClientStream) RecvMsg(m interface{}) error {
+	start := s.tracer.Now()
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		// The response stream is drained, the call is successful.
+		s.end(nil)
+		return err
+	}
+	s.tracer.MessageSpan(s.ctx, s.name+"/recv", "RECEIVED", atomic.AddInt64(&s.received, 1), start, err)
+	// The call with a single response ends with the response.
+	if err != nil || !s.desc.ServerStreams {
+		s.end(err)
+	}
+	return err
+}
+
+// End the span of the call once.
+func (s *tracingClientStream) end(err error) {
+	s.once.Do(func() {
+		tracing.FinishRpcSpan(s.span, err)
+	})
+}
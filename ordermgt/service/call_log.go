@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"grpc-up-and-running/common/calllog"
+	"grpc-up-and-running/common/interceptor"
+)
+
+// callLogger adds the service interceptors to the call logger of the common module.
+type callLogger struct {
+	*calllog.Logger
+}
+
+// Create a call logger writing to out.
+func newCallLogger(out io.Writer, config calllog.Config) (*callLogger, error) {
+	l, err := calllog.New(out, config)
+	if err != nil {
+		return nil, err
+	}
+	return &callLogger{l}, nil
+}
+
+type requestIdContextKey struct{}
+
+// Get the request ID of the call from the context, empty if there is none.
+func requestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdContextKey{}).(string)
+	return id
+}
+
+// Get the request ID sent by the client, or generate one if there is none.
+// The request ID is put into the context for the remote method.
+func serverRequestId(ctx context.Context) (context.Context, string) {
+	var requestId string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(calllog.RequestIdKey); len(values) > 0 {
+			requestId = values[0]
+		}
+	}
+	if requestId == "" {
+		requestId = calllog.NewRequestId()
+	}
+	return context.WithValue(ctx, requestIdContextKey{}, requestId), requestId
+}
+
+// Unary interceptor logging the calls, the request ID is sent back in the header.
+// The call is logged even if the handler panics, so the interceptor works on either side of the recovery interceptor.
+func (l *callLogger) unaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	start := l.Now()
+	ctx, requestId := serverRequestId(ctx)
+	grpc.SetHeader(ctx, metadata.Pairs(calllog.RequestIdKey, requestId))
+	method := interceptor.FullMethod(ctx, info.FullMethod)
+	sampled := l.Sampled(method)
+	if sampled {
+		l.LogMessage("request received", requestId, method, req)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			l.Finish(requestId, method, start, sampled, interceptor.PanicError(r))
+			panic(r)
+		}
+		if sampled && err == nil {
+			l.LogMessage("response sent", requestId, method, res)
+		}
+		l.Finish(requestId, method, start, sampled, err)
+	}()
+
+	return handler(ctx, req)
+}
+
+// Stream interceptor logging the calls with the numbers of the received and sent messages.
+func (l *callLogger) streamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := l.Now()
+	ctx, requestId := serverRequestId(ss.Context())
+	ss.SetHeader(metadata.Pairs(calllog.RequestIdKey, requestId))
+	method := interceptor.FullMethod(ctx, info.FullMethod)
+	stream := &loggingServerStream{ServerStream: ss, ctx: ctx, logger: l, requestId: requestId, method: method, sampled: l.Sampled(method)}
+	defer func() {
+		r := recover()
+		if r != nil {
+			err = interceptor.PanicError(r)
+		}
+		l.Finish(requestId, method, start, stream.sampled, err, calllog.Field{Key: "received", Value: atomic.LoadInt64(&stream.received)}, calllog.Field{Key: "sent", Value: atomic.LoadInt64(&stream.sent)})
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	return handler(srv, stream)
+}
+
+// loggingServerStream counts the messages of the stream and logs their payloads at the debug level.
+type loggingServerStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	logger    *callLogger
+	requestId string
+	method    string
+	sampled   bool
+	received  int64 // Updated atomically, the messages may be received in another goroutine.
+	sent      int64
+}
+
+func (s *loggingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *loggingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	atomic.AddInt64(&s.received, 1)
+	if s.sampled {
+		s.logger.LogMessage("message received", s.requestId, s.method, m)
+	}
+	return nil
+}
+
+func (s *loggingServerStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	atomic.AddInt64(&s.sent, 1)
+	if s.sampled {
+		s.logger.LogMessage("message sent", s.requestId, s.method, m)
+	}
+	return nil
+}
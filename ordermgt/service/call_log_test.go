@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"grpc-up-and-running/common/calllog"
+	"grpc-up-and-running/common/recovery/recoverytest"
+)
+
+// Create a call logger with a fixed clock.
+func newTestCallLogger(t *testing.T, out *recoverytest.SyncBuffer, config calllog.Config) *callLogger {
+	l, err := newCallLogger(out, config)
+	if err != nil {
+		t.Fatalf("newCallLogger() error: %v", err)
+	}
+	l.Now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return l
+}
+
+func TestCallLogger_Interceptors(t *testing.T) {
+	var out recoverytest.SyncBuffer
+	l := newTestCallLogger(t, &out, calllog.Config{Format: "logfmt", Level: calllog.LevelInfo, Sampling: map[string]float64{"getOrder": 0}})
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store),
+		grpc.UnaryInterceptor(l.unaryServerInterceptor),
+		grpc.StreamInterceptor(l.streamServerInterceptor))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// The successful getOrder isn't sampled, but the failed one is always logged.
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(ctx, calllog.RequestIdKey, "req-1")
+	if _, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "102"}, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+	if got := header.Get(calllog.RequestIdKey); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("header %s = %v, want [req-1]", calllog.RequestIdKey, got)
+	}
+	client.GetOrder(ctx, &wrapper.StringValue{Value: "999"})
+	processOrders(t, ctx, client, "102", "103")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2:\n%s", len(lines), out.String())
+	}
+	for i, want := range []string{
+		`level=warn msg="call finished" request_id=req-1 method=/ecommerce.OrderManagement/getOrder code=NotFound`,
+		`level=info msg="call finished" request_id=req-1 method=/ecommerce.OrderManagement/processOrders code=OK`,
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("log line %d = %q, want it to contain %q", i, lines[i], want)
+		}
+	}
+	if !strings.Contains(lines[1], "received=2 sent=") {
+		t.Errorf("log line 1 = %q, want the message counts", lines[1])
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultCallDeadline = 30 * time.Second
+	maxCallDeadline     = 5 * time.Minute
+)
+
+// The methods which are open-ended by design, they don't get the default deadline:
+// the watch, and the streams of the orders sent by the client (e.g. a bulk import or a long-lived ProcessOrders stream),
+// which last as long as the client keeps sending.
+// They are still bounded by the max deadline, and the client can set a shorter deadline on them.
+var openEndedMethods = map[string]bool{
+	"/ecommerce.OrderManagement/watchOrders":   true,
+	"/ecommerce.OrderManagement/addOrders":     true,
+	"/ecommerce.OrderManagement/updateOrders":  true,
+	"/ecommerce.OrderManagement/processOrders": true,
+}
+
+// deadlinePolicy bounds how long a call can run on the server.
+// A call without deadline gets the default deadline (except the open-ended methods), and the deadline of every call is capped at the max deadline.
+// Zero disables the default deadline or the max deadline.
+type deadlinePolicy struct {
+	defaultDeadline time.Duration
+	maxDeadline     time.Duration
+}
+
+// Check the deadlines are not negative, and the default deadline doesn't exceed the max deadline.
+func (p deadlinePolicy) validate() error {
+	if p.defaultDeadline < 0 || p.maxDeadline < 0 {
+		return fmt.Errorf("deadlines must not be negative, got default %v and max %v", p.defaultDeadline, p.maxDeadline)
+	}
+	if p.maxDeadline > 0 && p.defaultDeadline > p.maxDeadline {
+		return fmt.Errorf("default deadline %v exceeds max deadline %v", p.defaultDeadline, p.maxDeadline)
+	}
+	return nil
+}
+
+// Apply the policy on the context of the call, the returned cancel function must be called when the call is done.
+// The open-ended call without deadline is only bounded by the max deadline.
+func (p deadlinePolicy) apply(ctx context.Context, openEnded bool) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	switch {
+	case !ok && !openEnded && p.defaultDeadline > 0:
+		return context.WithTimeout(ctx, p.defaultDeadline)
+	case p.maxDeadline > 0 && (!ok || time.Until(deadline) > p.maxDeadline):
+		return context.WithTimeout(ctx, p.maxDeadline)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Unary interceptor enforcing the deadline policy.
+// The call which is already cancelled or past its deadline is rejected before running the remote method,
+// and the context errors returned by the remote method are converted into Canceled or DeadlineExceeded.
+func (p deadlinePolicy) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, cancel := p.apply(ctx, false)
+	defer cancel()
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+	m, err := handler(ctx, req)
+	return m, toStatusError(err)
+}
+
+// Stream interceptor enforcing the deadline policy, the open-ended methods don't get the default deadline.
+func (p deadlinePolicy) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, cancel := p.apply(ss.Context(), openEndedMethods[info.FullMethod])
+	defer cancel()
+	if ctx.Err() != nil {
+		return contextError(ctx)
+	}
+	return toStatusError(handler(srv, &deadlineStream{ss, ctx}))
+}
+
+// deadlineStream replaces the context of the stream with the one bounded by the deadline policy.
+type deadlineStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *deadlineStream) Context() context.Context {
+	return s.ctx
+}
+
+// Convert the error of the done context into the status error, Canceled or DeadlineExceeded.
+func contextError(ctx context.Context) error {
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+// Convert the plain context errors into the status errors, the other errors are returned as is.
+func toStatusError(err error) error {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
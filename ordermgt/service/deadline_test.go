@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/interceptor"
+)
+
+func TestDeadlinePolicy_Apply(t *testing.T) {
+	policy := deadlinePolicy{defaultDeadline: time.Second, maxDeadline: time.Minute}
+	tests := []struct {
+		name      string
+		timeout   time.Duration // 0 for no deadline.
+		openEnded bool
+		policy    deadlinePolicy
+		want      time.Duration // 0 for no deadline.
+	}{
+		{"default", 0, false, policy, time.Second},
+		{"kept", 10 * time.Second, false, policy, 10 * time.Second},
+		{"capped", time.Hour, false, policy, time.Minute},
+		{"no default", 0, false, deadlinePolicy{maxDeadline: time.Minute}, time.Minute},
+		{"disabled", 0, false, deadlinePolicy{}, 0},
+		{"open-ended", 0, true, policy, time.Minute},
+		{"open-ended kept", 10 * time.Second, true, policy, 10 * time.Second},
+		{"open-ended capped", time.Hour, true, policy, time.Minute},
+		{"open-ended no max", 0, true, deadlinePolicy{defaultDeadline: time.Second}, 0},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+			defer cancel()
+		}
+		ctx, cancel := tt.policy.apply(ctx, tt.openEnded)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		if tt.want == 0 {
+			if ok {
+				t.Errorf("%s: apply() deadline = %v, want none", tt.name, deadline)
+			}
+			continue
+		}
+		if got := time.Until(deadline); !ok || got > tt.want || got < tt.want-time.Second/2 {
+			t.Errorf("%s: apply() deadline in %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if err := (deadlinePolicy{defaultDeadline: time.Hour, maxDeadline: time.Minute}).validate(); err == nil {
+		t.Errorf("validate() with default deadline over max deadline got no error")
+	}
+}
+
+func TestDeadlinePolicy_StreamInterceptor(t *testing.T) {
+	policy := deadlinePolicy{defaultDeadline: time.Second, maxDeadline: time.Minute}
+	// The open-ended streams without deadline only get the max deadline, the other streams get the default deadline.
+	for method, want := range map[string]time.Duration{
+		"/ecommerce.OrderManagement/processOrders": time.Minute,
+		"/ecommerce.OrderManagement/watchOrders":   time.Minute,
+		"/ecommerce.OrderManagement/addOrders":     time.Minute,
+		"/ecommerce.OrderManagement/searchOrders":  time.Second,
+	} {
+		var got time.Duration
+		err := policy.streamInterceptor(nil, &deadlineStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: method}, func(srv interface{}, ss grpc.ServerStream) error {
+			if deadline, ok := ss.Context().Deadline(); ok {
+				got = time.Until(deadline)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s error: %v", method, err)
+		}
+		if got > want || got < want-time.Second/2 {
+			t.Errorf("%s deadline in %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestDeadlinePolicy_StreamInterceptorDeadlineExceeded(t *testing.T) {
+	policy := deadlinePolicy{defaultDeadline: 100 * time.Millisecond, maxDeadline: time.Second}
+	// The stream without deadline is ended by the default deadline, even if the client never sends anything.
+	err := policy.streamInterceptor(nil, &deadlineStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/ecommerce.OrderManagement/searchOrders"}, func(srv interface{}, ss grpc.ServerStream) error {
+		<-ss.Context().Done()
+		return ss.Context().Err()
+	})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("searchOrders without deadline got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestOrderMgtServer_DeadlineCancel(t *testing.T) {
+	policy := deadlinePolicy{defaultDeadline: time.Minute, maxDeadline: time.Hour}
+	// Record when the remote method starts and how it ends on the server.
+	started := make(chan struct{})
+	ended := make(chan error, 1)
+	record := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		close(started)
+		err := handler(srv, ss)
+		ended <- err
+		return err
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()),
+		grpc.StreamInterceptor(interceptor.ChainStreamServer(record, policy.streamInterceptor, orderServerStreamInterceptor)))
+	defer stop()
+
+	// The client cancelling the stream ends it on the server too, while the server is waiting for the orders.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := client.UpdateOrders(ctx); err != nil {
+		t.Fatalf("UpdateOrders() error: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("UpdateOrders() not started on the server")
+	}
+	cancel()
+	select {
+	case err := <-ended:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("UpdateOrders() after cancel ended on the server by %v, want Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("UpdateOrders() not ended on the server after cancel")
+	}
+}
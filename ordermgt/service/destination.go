@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The policies of grouping the orders into the combined shipments by their destinations.
+const (
+	groupByExact  = "exact"  // The same full address (street, city, region and postal code).
+	groupByCity   = "city"   // The same city in the same region.
+	groupByRegion = "region" // The same region (state or province).
+)
+
+var postalCodePattern = regexp.MustCompile(`^\d{5}(-\d{4})?$`)
+
+// destination is a parsed order destination in the form of "[street, ]city[, region][ postal code]",
+// e.g. "1600 Amphitheatre Pkwy, Mountain View, CA 94043" or "San Jose, CA".
+// The parts which are not given are left empty.
+type destination struct {
+	street     string
+	city       string
+	region     string
+	postalCode string
+}
+
+// Parse the destination and normalize the parts:
+// the spaces are collapsed, the city is title-cased, and the short region codes are upper-cased.
+// The postal code can be the last comma-separated part, or follow the region in the same part.
+func parseDestination(s string) destination {
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	var d destination
+	if n := len(parts); n > 0 {
+		last := strings.Fields(parts[n-1])
+		if code := last[len(last)-1]; postalCodePattern.MatchString(code) {
+			d.postalCode = code
+			if len(last) == 1 {
+				parts = parts[:n-1]
+			} else {
+				parts[n-1] = strings.Join(last[:len(last)-1], " ")
+			}
+		}
+	}
+
+	switch n := len(parts); {
+	case n == 1:
+		d.city = titleCase(parts[0])
+	case n >= 2:
+		d.street = strings.Join(parts[:n-2], ", ")
+		d.city = titleCase(parts[n-2])
+		d.region = parts[n-1]
+		if len(d.region) <= 3 {
+			d.region = strings.ToUpper(d.region)
+		} else {
+			d.region = titleCase(d.region)
+		}
+	}
+	return d
+}
+
+// Format the destination in the canonical form, e.g. "1600 Amphitheatre Pkwy, Mountain View, CA 94043".
+func (d destination) String() string {
+	var parts []string
+	for _, part := range []string{d.street, d.city, strings.TrimSpace(d.region + " " + d.postalCode)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Get the part of the destination the orders are grouped by under the policy.
+// The part is formatted for display, e.g. "Mountain View, CA" for the city policy.
+// If the destination lacks the region (or the city), the finer part is used instead,
+// so the orders with an unknown region are never grouped together.
+func (d destination) group(policy string) string {
+	switch {
+	case policy == groupByRegion && d.region != "":
+		return d.region
+	case (policy == groupByRegion || policy == groupByCity) && d.city != "":
+		return destination{city: d.city, region: d.region}.String()
+	}
+	return d.String()
+}
+
+// Check the grouping policy is one of the known policies.
+func validateGroupingPolicy(policy string) error {
+	switch policy {
+	case groupByExact, groupByCity, groupByRegion:
+		return nil
+	}
+	return fmt.Errorf("must be one of %s, %s and %s, got %q", groupByExact, groupByCity, groupByRegion, policy)
+}
+
+// Capitalize the first letter and lower-case the rest of each word, e.g. "mountain VIEW" becomes "Mountain View".
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + strings.ToLower(word[size:])
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestParseDestination(t *testing.T) {
+	tests := []struct {
+		in   string
+		want destination
+	}{
+		{"Mountain View, CA", destination{city: "Mountain View", region: "CA"}},
+		{"  mountain   view ,ca  ", destination{city: "Mountain View", region: "CA"}},
+		{"1600 Amphitheatre Pkwy, Mountain View, CA 94043", destination{street: "1600 Amphitheatre Pkwy", city: "Mountain View", region: "CA", postalCode: "94043"}},
+		{"San Jose, CA, 95134-1706", destination{city: "San Jose", region: "CA", postalCode: "95134-1706"}},
+		{"Toronto, ontario", destination{city: "Toronto", region: "Ontario"}},
+		{"London", destination{city: "London"}},
+		{"", destination{}},
+	}
+	for _, test := range tests {
+		if got := parseDestination(test.in); got != test.want {
+			t.Errorf("parseDestination(%q) = %+v, want %+v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestDestination_Group(t *testing.T) {
+	d := parseDestination("1600 amphitheatre pkwy,mountain view, ca 94043")
+	tests := map[string]string{
+		groupByExact:  "1600 amphitheatre pkwy, Mountain View, CA 94043",
+		groupByCity:   "Mountain View, CA",
+		groupByRegion: "CA",
+	}
+	for policy, want := range tests {
+		if got := d.group(policy); got != want {
+			t.Errorf("group(%s) = %q, want %q", policy, got, want)
+		}
+	}
+
+	// The destination without region falls back to the city.
+	if got := parseDestination("London").group(groupByRegion); got != "London" {
+		t.Errorf("group(%s) without region = %q, want %q", groupByRegion, got, "London")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	"grpc-up-and-running/common/idempotency"
+	pb "ordergmt/service/ecommerce"
+)
+
+func TestOrderMgtServer_AddOrderIdempotent(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, idempotency.KeyHeader, "add-101")
+
+	order := &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)}
+	first, err := client.AddOrder(ctx, order)
+	if err != nil {
+		t.Fatalf("AddOrder() error: %v", err)
+	}
+	if _, err := client.CancelOrder(ctx, &wrapper.StringValue{Value: "101"}); err != nil {
+		t.Fatalf("CancelOrder() error: %v", err)
+	}
+
+	// The retry gets the original result and doesn't replace the cancelled order.
+	retry, err := client.AddOrder(ctx, order)
+	if err != nil {
+		t.Fatalf("AddOrder() retry error: %v", err)
+	}
+	if retry.Value != first.Value {
+		t.Errorf("AddOrder() retry = %q, want %q", retry.Value, first.Value)
+	}
+	got, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "101"})
+	if err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+	if got.Status != pb.OrderStatus_CANCELLED {
+		t.Errorf("GetOrder() status = %v, want %v", got.Status, pb.OrderStatus_CANCELLED)
+	}
+
+	// The same key can't be reused by a different order.
+	other := &pb.Order{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "Mountain View, CA", Price: amount.New("USD", 550, 0)}
+	if _, err := client.AddOrder(ctx, other); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("AddOrder() with a reused key got %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// The default server pipeline, the metrics are the outermost interceptor to measure every call,
+// then the panics of the inner interceptors and the remote methods are recovered, the tracer continues the trace of the client,
+// the call logger logs the call and the deadline policy is applied before validating the request.
+const defaultServerInterceptors = "metrics,recovery,tracing,logging,deadline,validation"
+
+// serverInterceptor is one concern of the server pipeline, e.g. the metrics or the call logging.
+type serverInterceptor struct {
+	name     string
+	unary    grpc.UnaryServerInterceptor  // Nil if the concern doesn't apply to the unary calls.
+	stream   grpc.StreamServerInterceptor // Nil if the concern doesn't apply to the stream calls.
+	required bool                         // The interceptor can't be left out of the pipeline, e.g. the request validation.
+}
+
+// Parse the comma-separated interceptor names, e.g. "metrics,logging,validation".
+func parseInterceptorNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Assemble the server pipeline from the available interceptors by the names in order, the first one is the outermost.
+// Every name must be available and used once, and all the required interceptors must be used.
+func newServerPipeline(available []serverInterceptor, names []string) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor, error) {
+	byName := make(map[string]serverInterceptor)
+	for _, interceptor := range available {
+		byName[interceptor.name] = interceptor
+	}
+	used := make(map[string]bool)
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	for _, name := range names {
+		interceptor, ok := byName[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown interceptor %q, must be one of %s", name, serverInterceptorNames(available))
+		}
+		if used[name] {
+			return nil, nil, fmt.Errorf("interceptor %q is used more than once", name)
+		}
+		used[name] = true
+		if interceptor.unary != nil {
+			unary = append(unary, interceptor.unary)
+		}
+		if interceptor.stream != nil {
+			stream = append(stream, interceptor.stream)
+		}
+	}
+	for _, interceptor := range available {
+		if interceptor.required && !used[interceptor.name] {
+			return nil, nil, fmt.Errorf("interceptor %q is required", interceptor.name)
+		}
+	}
+	return unary, stream, nil
+}
+
+func serverInterceptorNames(interceptors []serverInterceptor) string {
+	names := make([]string, len(interceptors))
+	for i, interceptor := range interceptors {
+		names[i] = interceptor.name
+	}
+	return strings.Join(names, ", ")
+}
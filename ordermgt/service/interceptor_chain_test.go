@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"grpc-up-and-running/common/interceptor"
+)
+
+// Create a server interceptor recording its name in calls.
+func recordingServerInterceptor(name string, calls *[]string, unary, stream bool) serverInterceptor {
+	interceptor := serverInterceptor{name: name}
+	if unary {
+		interceptor.unary = func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			*calls = append(*calls, name)
+			return handler(ctx, req)
+		}
+	}
+	if stream {
+		interceptor.stream = func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			*calls = append(*calls, name)
+			return handler(srv, ss)
+		}
+	}
+	return interceptor
+}
+
+func TestNewServerPipeline(t *testing.T) {
+	var calls []string
+	available := []serverInterceptor{
+		recordingServerInterceptor("metrics", &calls, true, true),
+		recordingServerInterceptor("logging", &calls, true, false),
+		recordingServerInterceptor("streams", &calls, false, true),
+		recordingServerInterceptor("validation", &calls, true, true),
+	}
+	available[3].required = true
+
+	// The pipeline follows the configured order, and skips the interceptors not applying to the calls.
+	unary, stream, err := newServerPipeline(available, parseInterceptorNames(" validation, logging ,metrics,streams,"))
+	if err != nil {
+		t.Fatalf("newServerPipeline() error: %v", err)
+	}
+	interceptor.ChainUnaryServer(unary...)(context.Background(), "req", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls = append(calls, "handler")
+		return req, nil
+	})
+	if got := fmt.Sprint(calls); got != "[validation logging metrics handler]" {
+		t.Errorf("unary calls = %s, want [validation logging metrics handler]", got)
+	}
+	calls = nil
+	interceptor.ChainStreamServer(stream...)(nil, nil, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	if got := fmt.Sprint(calls); got != "[validation metrics streams handler]" {
+		t.Errorf("stream calls = %s, want [validation metrics streams handler]", got)
+	}
+
+	for _, c := range []struct {
+		names string
+		err   string
+	}{
+		{"validation,auth", `unknown interceptor "auth"`},
+		{"metrics,validation,metrics", `interceptor "metrics" is used more than once`},
+		{"metrics,logging", `interceptor "validation" is required`},
+	} {
+		_, _, err := newServerPipeline(available, parseInterceptorNames(c.names))
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("newServerPipeline(%q) error = %v, want %s", c.names, err, c.err)
+		}
+	}
+}
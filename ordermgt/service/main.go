@@ -1,9 +1,19 @@
 package main
 
 import (
+	"flag"
 	"google.golang.org/grpc"
+	"grpc-up-and-running/common/idempotency"
 	"log"
 	"net"
+	"os"
+	"strings"
+	"grpc-up-and-running/common/amount"
+	"grpc-up-and-running/common/calllog"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/metrics"
+	"grpc-up-and-running/common/recovery"
+	"grpc-up-and-running/common/tracing"
 	ordermgt_pb "ordergmt/service/ecommerce"
 	hello_pb "google.golang.org/grpc/examples/helloworld/helloworld"
 )
@@ -12,21 +22,110 @@ const (
 	port = ":50051"
 )
 
+var (
+	storeType = flag.String("store", "memory", "The type of the order store and the shipment store: memory or file")
+	storePath = flag.String("store-path", "orders.log", "The path of the order log file, only used by the file store")
+	shipmentStorePath = flag.String("shipment-store-path", "shipments.log", "The path of the shipment log file, only used by the file store")
+	batchSize = flag.Int("batch-size", defaultBatchSize, "The default max number of orders in one batch of ProcessOrders")
+	batchWait = flag.Duration("batch-wait", defaultBatchWait, "The default max idle time before ProcessOrders flushes the batch, 0 disables it")
+	grouping = flag.String("grouping", groupByExact, "The default policy of grouping the orders into the combined shipments by destination: exact, city or region")
+	idempotencyTTL = flag.Duration("idempotency-ttl", idempotency.DefaultTTL, "How long the results of AddOrder are remembered for the retries with the same idempotency key")
+	defaultDeadline = flag.Duration("default-deadline", defaultCallDeadline, "The deadline of the calls arriving without one, 0 disables it")
+	maxDeadline = flag.Duration("max-deadline", maxCallDeadline, "The max deadline of the calls, the longer deadlines are shortened to it, 0 disables it")
+	logFormat = flag.String("log-format", "logfmt", "The format of the call logs: json or logfmt")
+	logLevelName = flag.String("log-level", "info", "The min level of the call logs: debug (with the payloads), info, warn or error")
+	logSampling = flag.String("log-sampling", "", "The fraction of the successful calls to log by method, e.g. getOrder=0.1,searchOrders=0")
+	logRedact = flag.String("log-redact", "", "The comma-separated payload fields to redact in the call logs, e.g. destination,price")
+	eventLogSize = flag.Int("event-log-size", defaultEventLogSize, "The number of the latest order events retained for resuming WatchOrders")
+	tracePath = flag.String("trace-path", "", "The path of the file the spans are exported to in the OTLP JSON format, empty disables the export")
+	interceptors = flag.String("interceptors", defaultServerInterceptors, "The comma-separated interceptors of the calls in order, the first one is the outermost: metrics, recovery, tracing, logging, deadline and validation (required)")
+	metricsAddr = flag.String("metrics-addr", ":9090", "The address of the HTTP server serving the metrics on /metrics, empty disables it")
+)
+
 func main() {
-	initSampleData()
+	flag.Parse()
+	store, err := newOrderStore(*storeType, *storePath)
+	if err != nil {
+		log.Fatalf("failed to open order store: %v", err)
+	}
+	defer store.Close()
+	shipments, err := newShipmentStore(*storeType, *shipmentStorePath)
+	if err != nil {
+		log.Fatalf("failed to open shipment store: %v", err)
+	}
+	defer shipments.Close()
+
+	if store.Len() == 0 {
+		if err := initSampleData(store); err != nil {
+			log.Fatalf("failed to init sample data: %v", err)
+		}
+	}
+
+	orderServer, err := newOrderMgtServer(store)
+	if err != nil {
+		log.Fatalf("failed to create order management server: %v", err)
+	}
+	orderServer.batch = batchConfig{size: *batchSize, wait: *batchWait, grouping: *grouping}
+	if err := orderServer.batch.validate(); err != nil {
+		log.Fatalf("invalid batch parameters: %v", err)
+	}
+	if *eventLogSize < 1 {
+		log.Fatalf("invalid event log size: %d", *eventLogSize)
+	}
+	orderServer.events = newOrderEventLog(*eventLogSize)
+	orderServer.idempotency = idempotency.NewCache(*idempotencyTTL)
+	orderServer.shipments = shipments
+	recovered, err := orderServer.recoverProcessingOrders()
+	if err != nil {
+		log.Fatalf("failed to recover the orders in processing: %v", err)
+	}
+	if recovered > 0 {
+		log.Printf("Recovered %d orders left in PROCESSING by the last run", recovered)
+	}
+
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
+	deadlines := deadlinePolicy{defaultDeadline: *defaultDeadline, maxDeadline: *maxDeadline}
+	if err := deadlines.validate(); err != nil {
+		log.Fatalf("invalid deadlines: %v", err)
+	}
+	callLog, err := newServerCallLogger()
+	if err != nil {
+		log.Fatalf("invalid call log options: %v", err)
+	}
+	tracer, err := newServerTracer()
+	if err != nil {
+		log.Fatalf("failed to create the span exporter: %v", err)
+	}
+	serverMetrics := metrics.NewServerMetrics()
+	panicRecovery := recovery.New(log.New(os.Stderr, "", log.LstdFlags), serverMetrics)
+	// Each concern is its own interceptor, the pipeline is assembled in the order of the -interceptors flag.
+	unary, stream, err := newServerPipeline([]serverInterceptor{
+		{name: "metrics", unary: serverMetrics.UnaryInterceptor, stream: serverMetrics.StreamInterceptor},
+		{name: "recovery", unary: panicRecovery.UnaryInterceptor, stream: panicRecovery.StreamInterceptor},
+		{name: "tracing", unary: tracer.unaryServerInterceptor, stream: tracer.streamServerInterceptor},
+		{name: "logging", unary: callLog.unaryServerInterceptor, stream: callLog.streamServerInterceptor},
+		{name: "deadline", unary: deadlines.unaryInterceptor, stream: deadlines.streamInterceptor},
+		{name: "validation", unary: orderUnaryServerInterceptor, stream: orderServerStreamInterceptor, required: true},
+	}, parseInterceptorNames(*interceptors))
+	if err != nil {
+		log.Fatalf("invalid interceptors: %v", err)
+	}
 	s := grpc.NewServer(
-		grpc.UnaryInterceptor(orderUnaryServerInterceptor),     // Register unary interceptor.
-		grpc.StreamInterceptor(orderServerStreamInterceptor))   // Register stream interceptor.
+		grpc.UnaryInterceptor(interceptor.ChainUnaryServer(unary...)),    // Register unary interceptors.
+		grpc.StreamInterceptor(interceptor.ChainStreamServer(stream...))) // Register stream interceptors.
 
 	// Register 2 services: OrderManagement and Hello
 	// Example of Multiplexing - Run multiple services on one gRPC server
-	ordermgt_pb.RegisterOrderManagementServer(s, &orderMgtServer{})
+	ordermgt_pb.RegisterOrderManagementServer(s, orderServer)
 	hello_pb.RegisterGreeterServer(s, &helloServer{})
 
+	if *metricsAddr != "" {
+		metrics.Serve(*metricsAddr, serverMetrics)
+	}
+
 	log.Printf("Starting gRPC listener on port " + port)
 
 	if err := s.Serve(lis); err != nil {
@@ -34,10 +133,51 @@ func main() {
 	}
 }
 
-func initSampleData() {
-	orderMap["102"] = ordermgt_pb.Order{Id: "102", Items: []string{"Google Pixel 3A", "Mac Book Pro"},     Destination: "Mountain View, CA", Price: 1800.00}
-	orderMap["103"] = ordermgt_pb.Order{Id: "103", Items: []string{"Apple Watch S4"},                      Destination: "San Jose, CA",      Price: 400.00}
-	orderMap["104"] = ordermgt_pb.Order{Id: "104", Items: []string{"Google Home Mini", "Google Nest Hub"}, Destination: "Mountain View, CA", Price: 400.00}
-	orderMap["105"] = ordermgt_pb.Order{Id: "105", Items: []string{"Amazon Echo"},                         Destination: "San Jose, CA",      Price: 30.00}
-	orderMap["106"] = ordermgt_pb.Order{Id: "106", Items: []string{"Amazon Echo", "Apple iPhone XS"},      Destination: "Mountain View, CA", Price: 300.00}
+// Fill the sample orders into an empty order store.
+func initSampleData(store OrderStore) error {
+	orders := []*ordermgt_pb.Order{
+		{Id: "102", Items: []string{"Google Pixel 3A", "Mac Book Pro"},     Destination: "Mountain View, CA", Price: amount.New("USD", 1800, 0)},
+		{Id: "103", Items: []string{"Apple Watch S4"},                      Destination: "San Jose, CA",      Price: amount.New("USD", 400, 0)},
+		{Id: "104", Items: []string{"Google Home Mini", "Google Nest Hub"}, Destination: "Mountain View, CA", Price: amount.New("USD", 400, 0)},
+		{Id: "105", Items: []string{"Amazon Echo"},                         Destination: "San Jose, CA",      Price: amount.New("USD", 30, 0)},
+		{Id: "106", Items: []string{"Amazon Echo", "Apple iPhone XS"},      Destination: "Mountain View, CA", Price: amount.New("USD", 300, 0)},
+	}
+	for _, order := range orders {
+		order.Version = 1
+		if err := store.Put(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create the call logger writing to stderr by the flags.
+func newServerCallLogger() (*callLogger, error) {
+	level, err := calllog.ParseLevel(*logLevelName)
+	if err != nil {
+		return nil, err
+	}
+	sampling, err := calllog.ParseSampling(*logSampling)
+	if err != nil {
+		return nil, err
+	}
+	var redact []string
+	for _, field := range strings.Split(*logRedact, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			redact = append(redact, field)
+		}
+	}
+	return newCallLogger(os.Stderr, calllog.Config{Format: *logFormat, Level: level, Sampling: sampling, Redact: redact})
+}
+
+// Create the tracer exporting the spans to the trace file, the spans are only propagated if there is no trace file.
+func newServerTracer() (*tracer, error) {
+	if *tracePath == "" {
+		return newTracer(nil), nil
+	}
+	exporter, err := tracing.NewFileSpanExporter(*tracePath, "ordermgt-service")
+	if err != nil {
+		return nil, err
+	}
+	return newTracer(exporter), nil
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"grpc-up-and-running/common/metrics"
+)
+
+func TestServerMetrics_Interceptors(t *testing.T) {
+	serverMetrics := metrics.NewServerMetrics()
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store),
+		grpc.UnaryInterceptor(serverMetrics.UnaryInterceptor),
+		grpc.StreamInterceptor(serverMetrics.StreamInterceptor))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if _, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "102"}); err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+	client.GetOrder(ctx, &wrapper.StringValue{Value: "999"})
+	processOrders(t, ctx, client, "102", "103")
+
+	rec := httptest.NewRecorder()
+	serverMetrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the text exposition format", got)
+	}
+	body := rec.Body.String()
+	getOrder := `grpc_type="unary",grpc_service="ecommerce.OrderManagement",grpc_method="getOrder"`
+	processOrdersLabels := `grpc_type="bidi_stream",grpc_service="ecommerce.OrderManagement",grpc_method="processOrders"`
+	for _, want := range []string{
+		"# TYPE grpc_server_handling_seconds histogram\n",
+		"grpc_server_started_total{" + getOrder + "} 2\n",
+		"grpc_server_handled_total{" + getOrder + `,grpc_code="NotFound"} 1` + "\n",
+		"grpc_server_handled_total{" + getOrder + `,grpc_code="OK"} 1` + "\n",
+		"grpc_server_handling_seconds_bucket{" + getOrder + `,le="+Inf"} 2` + "\n",
+		"grpc_server_handling_seconds_count{" + getOrder + "} 2\n",
+		"grpc_server_msg_received_total{" + getOrder + "} 2\n",
+		"grpc_server_msg_sent_total{" + getOrder + "} 1\n",
+		"grpc_server_handled_total{" + processOrdersLabels + `,grpc_code="OK"} 1` + "\n",
+		"grpc_server_streams_in_flight{" + processOrdersLabels + "} 0\n",
+		"grpc_server_msg_received_total{" + processOrdersLabels + "} 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics don't contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "grpc_server_streams_in_flight{"+getOrder) {
+		t.Errorf("metrics contain the streams in flight of the unary method:\n%s", body)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultBatchSize = 3
+	defaultBatchWait = time.Second
+
+	// The upper limits of the batch parameters which can be requested by the client.
+	maxBatchSize = 100
+	maxBatchWait = time.Minute
+
+	// The metadata keys for the client to negotiate the batch parameters of ProcessOrders.
+	// The server sends the effective values back in the header with the same keys.
+	batchSizeKey = "batch-size"    // The max number of orders in one batch.
+	batchWaitKey = "batch-wait-ms" // The max idle time in milliseconds before flushing the batch, 0 disables it.
+	groupingKey  = "grouping"      // The policy of grouping the orders by destination: exact, city or region.
+)
+
+// batchConfig controls when ProcessOrders flushes the combined shipments to the client.
+// A batch is flushed when it has size orders, or when no order is received for wait since the last one.
+// The orders in a batch are combined into the shipments by their destinations under the grouping policy.
+type batchConfig struct {
+	size     int
+	wait     time.Duration
+	grouping string
+}
+
+// Check the batch parameters are within the limits.
+func (c batchConfig) validate() error {
+	if c.size < 1 || c.size > maxBatchSize {
+		return status.Errorf(codes.InvalidArgument, "%s must be between 1 and %d, got %d", batchSizeKey, maxBatchSize, c.size)
+	}
+	if c.wait < 0 || c.wait > maxBatchWait {
+		return status.Errorf(codes.InvalidArgument, "%s must be between 0 and %d, got %d", batchWaitKey, maxBatchWait.Milliseconds(), c.wait.Milliseconds())
+	}
+	if err := validateGroupingPolicy(c.grouping); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s %v", groupingKey, err)
+	}
+	return nil
+}
+
+// Build the metadata carrying the batch parameters.
+func (c batchConfig) metadata() metadata.MD {
+	return metadata.Pairs(
+		batchSizeKey, strconv.Itoa(c.size),
+		batchWaitKey, strconv.FormatInt(c.wait.Milliseconds(), 10),
+		groupingKey, c.grouping)
+}
+
+// Override the default batch parameters by the ones in the incoming metadata of the stream.
+func negotiateBatchConfig(ctx context.Context, defaults batchConfig) (batchConfig, error) {
+	c := defaults
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return c, nil
+	}
+	if values := md.Get(batchSizeKey); len(values) > 0 {
+		size, err := strconv.Atoi(values[0])
+		if err != nil {
+			return c, status.Errorf(codes.InvalidArgument, "invalid %s: %q", batchSizeKey, values[0])
+		}
+		c.size = size
+	}
+	if values := md.Get(batchWaitKey); len(values) > 0 {
+		ms, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil {
+			return c, status.Errorf(codes.InvalidArgument, "invalid %s: %q", batchWaitKey, values[0])
+		}
+		c.wait = time.Duration(ms) * time.Millisecond
+	}
+	if values := md.Get(groupingKey); len(values) > 0 {
+		c.grouping = values[0]
+	}
+	return c, c.validate()
+}
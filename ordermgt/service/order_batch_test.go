@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// A slow client should get the combined shipments after the batch wait window, without closing the stream.
+func TestOrderMgtServer_ProcessOrdersFlushOnWait(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store))
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	// The batch wait window is far longer than the gap between the two orders sent below,
+	// so both orders are in the batch when the window elapses.
+	ctx = metadata.AppendToOutgoingContext(ctx, batchSizeKey, "10", batchWaitKey, "500")
+	stream, err := client.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatalf("Header() error: %v", err)
+	}
+	if got := header.Get(batchSizeKey); len(got) != 1 || got[0] != "10" {
+		t.Errorf("header %s = %v, want [10]", batchSizeKey, got)
+	}
+
+	for _, id := range []string{"102", "104"} {
+		if err := stream.Send(&wrapper.StringValue{Value: id}); err != nil {
+			t.Fatalf("Send(%s) error: %v", id, err)
+		}
+	}
+	// The batch is not full, so the shipment can only be flushed by the batch wait window.
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error: %v", err)
+	}
+	if n := len(res.GetShipment().GetOrdersList()); n != 2 {
+		t.Errorf("Recv() got %d orders in the shipment, want 2", n)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend() error: %v", err)
+	}
+}
+
+func TestOrderMgtServer_ProcessOrdersInvalidBatchConfig(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+
+	for _, md := range []metadata.MD{
+		metadata.Pairs(batchSizeKey, "0"),
+		metadata.Pairs(batchSizeKey, "abc"),
+		metadata.Pairs(batchWaitKey, "-1"),
+		metadata.Pairs(groupingKey, "country"),
+	} {
+		ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), time.Second*5)
+		stream, err := client.ProcessOrders(ctx)
+		if err != nil {
+			t.Fatalf("ProcessOrders() error: %v", err)
+		}
+		if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ProcessOrders() with %v got %v, want InvalidArgument", md, err)
+		}
+		cancel()
+	}
+}
+
+// The orders to the same city written in different forms should be combined under the city policy.
+func TestOrderMgtServer_ProcessOrdersGrouping(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	for _, order := range []*pb.Order{
+		{Id: "101", Items: []string{"Google Home Mini"}, Destination: "Mountain View, CA", Price: amount.New("USD", 50, 0)},
+		{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "mountain view,ca", Price: amount.New("USD", 400, 0)},
+		{Id: "103", Items: []string{"Google Nest Hub"}, Destination: "1600 Amphitheatre Pkwy, Mountain View, CA 94043", Price: amount.New("USD", 130, 0)},
+		{Id: "104", Items: []string{"Amazon Echo"}, Destination: "San Jose, CA", Price: amount.New("USD", 30, 0)},
+	} {
+		if _, err := client.AddOrder(ctx, order); err != nil {
+			t.Fatalf("AddOrder() error: %v", err)
+		}
+	}
+
+	// The batch size is large enough to keep all the orders in one batch.
+	ctx = metadata.AppendToOutgoingContext(ctx, batchSizeKey, "10", groupingKey, groupByCity)
+	got := make(map[string]int)
+	for _, shipment := range processOrders(t, ctx, client, "101", "102", "103", "104") {
+		got[shipment.Destination] = len(shipment.OrdersList)
+	}
+	want := map[string]int{"Mountain View, CA": 3, "San Jose, CA": 1}
+	if len(got) != len(want) || got["Mountain View, CA"] != 3 || got["San Jose, CA"] != 1 {
+		t.Errorf("ProcessOrders() shipments = %v, want %v", got, want)
+	}
+}
+
+// Wait until the orders are changed back to CREATED by the events from the sequence number on.
+func waitForReleasedOrders(t *testing.T, ctx context.Context, srv *orderMgtServer, from uint64, orderIds ...string) {
+	t.Helper()
+	released := make(map[string]bool)
+	for len(released) < len(orderIds) {
+		events, next, wait, err := srv.events.read(from)
+		if err != nil {
+			t.Fatalf("read() error: %v", err)
+		}
+		for _, event := range events {
+			if event.Before.GetStatus() == pb.OrderStatus_PROCESSING && event.After.GetStatus() == pb.OrderStatus_CREATED {
+				released[event.After.Id] = true
+			}
+		}
+		from = next
+		if len(events) == 0 {
+			select {
+			case <-wait:
+			case <-ctx.Done():
+				t.Fatalf("orders released = %v, want %v", released, orderIds)
+			}
+		}
+	}
+}
+
+// The orders of the batch not flushed when the stream is cancelled can be processed again.
+func TestOrderMgtServer_ProcessOrdersCancelledBatch(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	srv := newTestOrderMgtServer(t, store)
+	client, stop := startOrderMgtServer(t, srv)
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	streamCtx, cancelStream := context.WithCancel(metadata.AppendToOutgoingContext(ctx, batchSizeKey, "10", batchWaitKey, "0"))
+	defer cancelStream()
+	stream, err := client.ProcessOrders(streamCtx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+	for _, id := range []string{"102", "103", "999"} {
+		if err := stream.Send(&wrapper.StringValue{Value: id}); err != nil {
+			t.Fatalf("Send(%s) error: %v", id, err)
+		}
+	}
+	// The orders are processed in order, so 102 and 103 are in PROCESSING once 999 is rejected.
+	res, err := stream.Recv()
+	if err != nil || res.GetError().GetOrderId() != "999" {
+		t.Fatalf("Recv() = %v, %v, want the error of 999", res, err)
+	}
+	for _, id := range []string{"102", "103"} {
+		if order, err := client.GetOrder(ctx, &wrapper.StringValue{Value: id}); err != nil || order.Status != pb.OrderStatus_PROCESSING {
+			t.Fatalf("GetOrder(%s) = %v, %v, want PROCESSING", id, order, err)
+		}
+	}
+	from, _ := srv.events.resume("")
+	cancelStream()
+
+	waitForReleasedOrders(t, ctx, srv, from, "102", "103")
+	var shipped []string
+	for _, shipment := range processOrders(t, ctx, client, "102", "103") {
+		for _, order := range shipment.OrdersList {
+			shipped = append(shipped, order.Id)
+		}
+	}
+	// The orders go to different destinations, so the shipments may be sent in any order.
+	sort.Strings(shipped)
+	if fmt.Sprint(shipped) != "[102 103]" {
+		t.Errorf("orders shipped after the cancel = %v, want [102 103]", shipped)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "ordergmt/service/ecommerce"
+)
+
+const defaultEventLogSize = 10000
+
+// orderEventLog keeps the latest order events in memory for WatchOrders.
+// The events are numbered by an increasing sequence number, only the latest size events are retained.
+// The resume token of an event is "<epoch>-<sequence>", the epoch tells the tokens issued by a previous process apart,
+// since the sequence starts over when the process restarts.
+type orderEventLog struct {
+	epoch int64
+
+	mu       sync.Mutex
+	events   []*pb.OrderEvent // Ring buffer of the retained events.
+	firstSeq uint64           // The sequence number of the oldest retained event.
+	nextSeq  uint64           // The sequence number of the next event.
+	notify   chan struct{}    // Closed and replaced when a new event is appended.
+}
+
+// Create an event log which retains the latest size events.
+func newOrderEventLog(size int) *orderEventLog {
+	return &orderEventLog{
+		epoch:    time.Now().UnixNano(),
+		events:   make([]*pb.OrderEvent, size),
+		firstSeq: 1,
+		nextSeq:  1,
+		notify:   make(chan struct{}),
+	}
+}
+
+// Record the change of an order and wake up all the watchers.
+// before is nil if the order is newly created.
+func (l *orderEventLog) append(before, after *pb.Order) {
+	event := &pb.OrderEvent{
+		Type:   orderEventType(before, after),
+		Before: before,
+		After:  after,
+		Time:   ptypes.TimestampNow(),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seq := l.nextSeq
+	event.ResumeToken = l.token(seq)
+	l.events[seq%uint64(len(l.events))] = event
+	l.nextSeq++
+	if l.nextSeq-l.firstSeq > uint64(len(l.events)) {
+		l.firstSeq++
+	}
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// Read copies of the events from the sequence number, and the sequence number to read from next time.
+// If there is no event yet, the returned channel is closed when a new event arrives.
+// Returns an OutOfRange error if the events from the sequence number have been discarded.
+func (l *orderEventLog) read(from uint64) ([]*pb.OrderEvent, uint64, <-chan struct{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if from < l.firstSeq {
+		return nil, from, nil, status.Errorf(codes.OutOfRange, "The events after the resume token have been discarded, please get the orders again and watch from now")
+	}
+	var events []*pb.OrderEvent
+	for seq := from; seq < l.nextSeq; seq++ {
+		events = append(events, proto.Clone(l.events[seq%uint64(len(l.events))]).(*pb.OrderEvent))
+	}
+	return events, l.nextSeq, l.notify, nil
+}
+
+// Get the sequence number of the first event to send for the resume token.
+// An empty token means only the new events are sent.
+func (l *orderEventLog) resume(token string) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if token == "" {
+		return l.nextSeq, nil
+	}
+	epoch, seq, err := parseResumeToken(token)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "Invalid resume token %q: %v", token, err)
+	}
+	if epoch != l.epoch {
+		return 0, status.Errorf(codes.OutOfRange, "The resume token %q was issued before the server restarted, please get the orders again and watch from now", token)
+	}
+	if seq >= l.nextSeq {
+		return 0, status.Errorf(codes.InvalidArgument, "Invalid resume token %q: unknown event", token)
+	}
+	return seq + 1, nil
+}
+
+// Build the resume token of the event with the sequence number.
+func (l *orderEventLog) token(seq uint64) string {
+	return fmt.Sprintf("%d-%d", l.epoch, seq)
+}
+
+// Parse the epoch and the sequence number from the resume token.
+func parseResumeToken(token string) (int64, uint64, error) {
+	parts := strings.Split(token, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("malformed token")
+	}
+	epoch, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return epoch, seq, nil
+}
+
+// Tell the type of the change from the order before and after it.
+func orderEventType(before, after *pb.Order) pb.OrderEvent_Type {
+	switch {
+	case before == nil:
+		return pb.OrderEvent_CREATED
+	case before.Status == after.Status:
+		return pb.OrderEvent_UPDATED
+	case after.Status == pb.OrderStatus_CANCELLED:
+		return pb.OrderEvent_CANCELLED
+	case after.Status == pb.OrderStatus_PROCESSING || after.Status == pb.OrderStatus_SHIPPED:
+		return pb.OrderEvent_PROCESSED
+	default:
+		return pb.OrderEvent_UPDATED
+	}
+}
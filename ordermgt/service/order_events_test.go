@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Receive n events from the watch stream.
+func recvEvents(t *testing.T, stream pb.OrderManagement_WatchOrdersClient, n int) []*pb.OrderEvent {
+	t.Helper()
+	var events []*pb.OrderEvent
+	for len(events) < n {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestOrderMgtServer_WatchOrders(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	watch, err := client.WatchOrders(watchCtx, &pb.WatchOrdersRequest{})
+	if err != nil {
+		t.Fatalf("WatchOrders() error: %v", err)
+	}
+	// Wait for the watch to be started, the events before it are not sent.
+	if _, err := watch.Header(); err != nil {
+		t.Fatalf("Header() error: %v", err)
+	}
+
+	if _, err := client.AddOrder(ctx, &pb.Order{Id: "301", Items: []string{"iPad Pro"}, Destination: "San Jose, CA", Price: amount.New("USD", 800, 0)}); err != nil {
+		t.Fatalf("AddOrder() error: %v", err)
+	}
+	update, err := client.UpdateOrders(ctx)
+	if err != nil {
+		t.Fatalf("UpdateOrders() error: %v", err)
+	}
+	update.Send(&pb.Order{Id: "301", Items: []string{"iPad Pro", "Apple Pencil"}, Destination: "San Jose, CA", Price: amount.New("USD", 900, 0)})
+	if _, err := update.CloseAndRecv(); err != nil {
+		t.Fatalf("UpdateOrders() error: %v", err)
+	}
+
+	events := recvEvents(t, watch, 2)
+	if events[0].Type != pb.OrderEvent_CREATED || events[0].Before != nil || events[0].After.Id != "301" {
+		t.Errorf("event 0 = %v, want CREATED of 301", events[0])
+	}
+	if events[1].Type != pb.OrderEvent_UPDATED || events[1].Before.Price.GetUnits() != 800 || events[1].After.Price.GetUnits() != 900 {
+		t.Errorf("event 1 = %v, want UPDATED of 301 from 800 to 900", events[1])
+	}
+	cancelWatch()
+
+	// The events happened while the client was disconnected are replayed from the resume token.
+	process, err := client.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+	process.Send(&wrapper.StringValue{Value: "301"})
+	process.CloseSend()
+	if _, err := process.Recv(); err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+
+	watch, err = client.WatchOrders(ctx, &pb.WatchOrdersRequest{ResumeToken: events[1].ResumeToken})
+	if err != nil {
+		t.Fatalf("WatchOrders() error: %v", err)
+	}
+	events = recvEvents(t, watch, 2)
+	for i, want := range []pb.OrderStatus{pb.OrderStatus_PROCESSING, pb.OrderStatus_SHIPPED} {
+		if events[i].Type != pb.OrderEvent_PROCESSED || events[i].After.Status != want {
+			t.Errorf("replayed event %d = %v, want PROCESSED to %s", i, events[i], want)
+		}
+	}
+
+	// The new events are sent after the replayed ones.
+	if _, err := client.AddOrder(ctx, &pb.Order{Id: "302", Items: []string{"Amazon Echo"}, Destination: "San Jose, CA", Price: amount.New("USD", 30, 0)}); err != nil {
+		t.Fatalf("AddOrder() error: %v", err)
+	}
+	if _, err := client.CancelOrder(ctx, &wrapper.StringValue{Value: "302"}); err != nil {
+		t.Fatalf("CancelOrder() error: %v", err)
+	}
+	events = recvEvents(t, watch, 2)
+	if events[1].Type != pb.OrderEvent_CANCELLED || events[1].Before.Status != pb.OrderStatus_CREATED {
+		t.Errorf("event = %v, want CANCELLED of 302", events[1])
+	}
+}
+
+func TestOrderEventLog_Resume(t *testing.T) {
+	l := newOrderEventLog(2)
+	for i := 0; i < 3; i++ {
+		l.append(nil, &pb.Order{Id: "401"})
+	}
+
+	// Only the latest 2 events are retained, the first one has been discarded.
+	from, err := l.resume(l.token(1))
+	if err != nil {
+		t.Fatalf("resume() error: %v", err)
+	}
+	events, _, _, err := l.read(from)
+	if err != nil || len(events) != 2 || events[0].ResumeToken != l.token(2) || events[1].ResumeToken != l.token(3) {
+		t.Errorf("read() after token 1 = %v, %v, want the events 2 and 3", events, err)
+	}
+	if _, _, _, err := l.read(1); status.Code(err) != codes.OutOfRange {
+		t.Errorf("read() discarded event got %v, want OutOfRange", err)
+	}
+
+	other := newOrderEventLog(2)
+	other.epoch = l.epoch + 1
+	if _, err := other.resume(l.token(1)); status.Code(err) != codes.OutOfRange {
+		t.Errorf("resume() token of another epoch got %v, want OutOfRange", err)
+	}
+	if _, err := l.resume("abc"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("resume() malformed token got %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	pb "ordergmt/service/ecommerce"
+)
+
+// orderIndex is an inverted index from the tokens of the items to the IDs of the orders having them.
+// It is used by SearchOrders to find the candidates without scanning all the orders.
+type orderIndex struct {
+	store  OrderStore
+	mu     sync.RWMutex
+	tokens map[string]map[string]struct{} // Token -> IDs of the orders having the token in their items.
+	orders map[string][]string            // Order ID -> tokens of the order's items, for removing the stale tokens.
+}
+
+// Create an index and build it from all the orders in the store.
+func newOrderIndex(store OrderStore) (*orderIndex, error) {
+	idx := &orderIndex{
+		store:  store,
+		tokens: make(map[string]map[string]struct{}),
+		orders: make(map[string][]string),
+	}
+	err := store.Range(func(order *pb.Order) bool {
+		idx.set(order)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Re-index the order by its current state in the store, must be called after the order is put into the store.
+// The latest state is read under the index lock, so concurrent updates on the same order always leave the index in sync with the store.
+func (idx *orderIndex) refresh(id string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	order, err := idx.store.Get(id)
+	if err == errOrderNotFound {
+		idx.remove(id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	idx.set(order)
+	return nil
+}
+
+// Replace the tokens of the order, the caller must hold the write lock.
+func (idx *orderIndex) set(order *pb.Order) {
+	idx.remove(order.Id)
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, item := range order.Items {
+		for _, token := range tokenize(item) {
+			if seen[token] {
+				continue
+			}
+			seen[token] = true
+			tokens = append(tokens, token)
+			ids, exists := idx.tokens[token]
+			if !exists {
+				ids = make(map[string]struct{})
+				idx.tokens[token] = ids
+			}
+			ids[order.Id] = struct{}{}
+		}
+	}
+	idx.orders[order.Id] = tokens
+}
+
+// Remove all the tokens of the order, the caller must hold the write lock.
+func (idx *orderIndex) remove(id string) {
+	for _, token := range idx.orders[id] {
+		ids := idx.tokens[token]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx.tokens, token)
+		}
+	}
+	delete(idx.orders, id)
+}
+
+// Find the IDs of the orders having all the tokens, sorted by order ID.
+func (idx *orderIndex) lookup(tokens []string) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Start from the token with the fewest orders to keep the intersection small.
+	var smallest map[string]struct{}
+	for _, token := range tokens {
+		ids := idx.tokens[token]
+		if len(ids) == 0 {
+			return nil
+		}
+		if smallest == nil || len(ids) < len(smallest) {
+			smallest = ids
+		}
+	}
+
+	var result []string
+	for id := range smallest {
+		matched := true
+		for _, token := range tokens {
+			if _, exists := idx.tokens[token][id]; !exists {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Split the text into lower-case tokens of letters and digits.
+// e.g. "Google Pixel 3A" -> ["google", "pixel", "3a"]
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
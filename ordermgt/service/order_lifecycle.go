@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "ordergmt/service/ecommerce"
+)
+
+// The legal transitions of the order status.
+// DELIVERED and CANCELLED are the final status, they can't be changed anymore.
+var orderTransitions = map[pb.OrderStatus][]pb.OrderStatus{
+	pb.OrderStatus_CREATED:    {pb.OrderStatus_PROCESSING, pb.OrderStatus_CANCELLED},
+	pb.OrderStatus_PROCESSING: {pb.OrderStatus_SHIPPED, pb.OrderStatus_CANCELLED},
+	pb.OrderStatus_SHIPPED:    {pb.OrderStatus_DELIVERED},
+}
+
+// Check whether the order can be moved from one status to another.
+func canTransition(from, to pb.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether the content of the order (items, description, price and destination) can be modified in the status.
+// The content is frozen once the order is shipped or cancelled.
+func isModifiable(s pb.OrderStatus) bool {
+	return s == pb.OrderStatus_CREATED || s == pb.OrderStatus_PROCESSING
+}
+
+// Move the order to the new status, returns a FailedPrecondition error if the transition is illegal.
+func transitionOrder(order *pb.Order, to pb.OrderStatus) error {
+	if !canTransition(order.Status, to) {
+		return newPreconditionError(order.Id, fmt.Sprintf("Order %s can't be moved from %s to %s", order.Id, order.Status, to))
+	}
+	order.Status = to
+	return nil
+}
+
+// Check the update from the current order to the new order follows the lifecycle.
+// The clients can't change the status by an update, it is only moved by ProcessOrders, except the cancellation by a legal transition.
+// The content can only be modified before the order is shipped.
+func checkOrderUpdate(current, updated *pb.Order) error {
+	if current == nil {
+		if updated.Status != pb.OrderStatus_CREATED {
+			return newPreconditionError(updated.Id, fmt.Sprintf("New order %s must be %s, got %s", updated.Id, pb.OrderStatus_CREATED, updated.Status))
+		}
+		return nil
+	}
+	if updated.Status != current.Status {
+		if updated.Status != pb.OrderStatus_CANCELLED {
+			return newStatusChangeError(updated.Id, current.Status, updated.Status)
+		}
+		if !canTransition(current.Status, updated.Status) {
+			return newPreconditionError(updated.Id, fmt.Sprintf("Order %s can't be moved from %s to %s", updated.Id, current.Status, updated.Status))
+		}
+	}
+	if !isModifiable(current.Status) && !sameOrderContent(current, updated) {
+		return newPreconditionError(updated.Id, fmt.Sprintf("Order %s can't be modified in %s", updated.Id, current.Status))
+	}
+	return nil
+}
+
+// Check the update is based on the current version of the order.
+// A zero version means the update is unconditional.
+// Returns an Aborted error with the PreconditionFailure details if the order has been changed (or removed) since the version was read.
+func checkOrderVersion(current, updated *pb.Order) error {
+	if updated.Version == 0 || updated.Version == current.GetVersion() {
+		return nil
+	}
+	description := fmt.Sprintf("Order %s has been changed, the current version is %d, got %d", updated.Id, current.GetVersion(), updated.Version)
+	errorStatus := status.New(codes.Aborted, description)
+	ds, err := errorStatus.WithDetails(&epb.PreconditionFailure{
+		Violations: []*epb.PreconditionFailure_Violation{{
+			Type:        "ORDER_VERSION",
+			Subject:     "ecommerce.Order/" + updated.Id,
+			Description: description,
+		}},
+	})
+	if err != nil {
+		return errorStatus.Err()
+	}
+	return ds.Err()
+}
+
+// Compare the content of two orders, ignoring the status and the version.
+func sameOrderContent(a, b *pb.Order) bool {
+	a = proto.Clone(a).(*pb.Order)
+	b = proto.Clone(b).(*pb.Order)
+	a.Status, b.Status = 0, 0
+	a.Version, b.Version = 0, 0
+	return proto.Equal(a, b)
+}
+
+// Build an InvalidArgument error with the BadRequest details for the status changed by an update.
+func newStatusChangeError(orderId string, from, to pb.OrderStatus) error {
+	errorStatus := status.New(codes.InvalidArgument, fmt.Sprintf("Order %s can't be moved from %s to %s by an update, only cancelled", orderId, from, to))
+	ds, err := errorStatus.WithDetails(&epb.BadRequest{
+		FieldViolations: []*epb.BadRequest_FieldViolation{{
+			Field:       "status",
+			Description: fmt.Sprintf("the status is changed by ProcessOrders, an update can only set %s", pb.OrderStatus_CANCELLED),
+		}},
+	})
+	if err != nil {
+		return errorStatus.Err()
+	}
+	return ds.Err()
+}
+
+// Build a FailedPrecondition error with the PreconditionFailure details for the order.
+func newPreconditionError(orderId string, description string) error {
+	errorStatus := status.New(codes.FailedPrecondition, description)
+	ds, err := errorStatus.WithDetails(&epb.PreconditionFailure{
+		Violations: []*epb.PreconditionFailure_Violation{{
+			Type:        "ORDER_STATUS",
+			Subject:     "ecommerce.Order/" + orderId,
+			Description: description,
+		}},
+	})
+	if err != nil {
+		return errorStatus.Err()
+	}
+	return ds.Err()
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to pb.OrderStatus
+		want     bool
+	}{
+		{pb.OrderStatus_CREATED, pb.OrderStatus_PROCESSING, true},
+		{pb.OrderStatus_CREATED, pb.OrderStatus_CANCELLED, true},
+		{pb.OrderStatus_CREATED, pb.OrderStatus_SHIPPED, false},
+		{pb.OrderStatus_PROCESSING, pb.OrderStatus_SHIPPED, true},
+		{pb.OrderStatus_PROCESSING, pb.OrderStatus_CANCELLED, true},
+		{pb.OrderStatus_SHIPPED, pb.OrderStatus_DELIVERED, true},
+		{pb.OrderStatus_SHIPPED, pb.OrderStatus_CANCELLED, false},
+		{pb.OrderStatus_DELIVERED, pb.OrderStatus_CREATED, false},
+		{pb.OrderStatus_CANCELLED, pb.OrderStatus_PROCESSING, false},
+	}
+	for _, tt := range tests {
+		if got := canTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+// Check the error is a FailedPrecondition error with the PreconditionFailure details.
+func assertPreconditionError(t *testing.T, name string, err error) {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition {
+		t.Errorf("%s got %v, want FailedPrecondition", name, err)
+		return
+	}
+	for _, d := range st.Details() {
+		if _, ok := d.(*epb.PreconditionFailure); ok {
+			return
+		}
+	}
+	t.Errorf("%s got no PreconditionFailure details in %v", name, st.Details())
+}
+
+func TestOrderMgtServer_OrderLifecycle(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	order := &pb.Order{Id: "201", Items: []string{"Apple Watch S4"}, Destination: "San Jose, CA", Price: amount.New("USD", 400, 0)}
+	for _, o := range []*pb.Order{order, {Id: "202", Items: []string{"Amazon Echo"}, Destination: "San Jose, CA", Price: amount.New("USD", 30, 0)}} {
+		if _, err := client.AddOrder(ctx, o); err != nil {
+			t.Fatalf("AddOrder(%s) error: %v", o.Id, err)
+		}
+	}
+
+	// Ship order 201 by ProcessOrders.
+	stream, err := client.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+	if err := stream.Send(&wrapper.StringValue{Value: "201"}); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	stream.CloseSend()
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error: %v", err)
+		}
+		if res.GetShipment().GetStatus() != pb.OrderStatus_SHIPPED {
+			t.Errorf("shipment status = %s, want SHIPPED", res.GetShipment().GetStatus())
+		}
+	}
+	shipped, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "201"})
+	if err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+	if shipped.Status != pb.OrderStatus_SHIPPED {
+		t.Errorf("GetOrder() status = %s, want SHIPPED", shipped.Status)
+	}
+
+	// A shipped order can't be cancelled or modified.
+	_, err = client.CancelOrder(ctx, &wrapper.StringValue{Value: "201"})
+	assertPreconditionError(t, "CancelOrder(201)", err)
+
+	update := func(o *pb.Order) error {
+		stream, err := client.UpdateOrders(ctx)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(o); err != nil {
+			return err
+		}
+		res, err := stream.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		// The outcome of the order is reported in the response.
+		return status.FromProto(res.Results[0].Status).Err()
+	}
+	modified := *shipped
+	modified.Destination = "Mountain View, CA"
+	assertPreconditionError(t, "UpdateOrders() on shipped order", update(&modified))
+
+	delivered := *shipped
+	delivered.Status = pb.OrderStatus_DELIVERED
+	if err := update(&delivered); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateOrders() to DELIVERED got %v, want InvalidArgument", err)
+	}
+
+	// An order waiting for processing can be cancelled only once.
+	cancelled, err := client.CancelOrder(ctx, &wrapper.StringValue{Value: "202"})
+	if err != nil {
+		t.Fatalf("CancelOrder(202) error: %v", err)
+	}
+	if cancelled.Status != pb.OrderStatus_CANCELLED {
+		t.Errorf("CancelOrder(202) status = %s, want CANCELLED", cancelled.Status)
+	}
+	_, err = client.CancelOrder(ctx, &wrapper.StringValue{Value: "202"})
+	assertPreconditionError(t, "CancelOrder(202) twice", err)
+
+	if _, err := client.CancelOrder(ctx, &wrapper.StringValue{Value: "999"}); status.Code(err) != codes.NotFound {
+		t.Errorf("CancelOrder(999) got %v, want NotFound", err)
+	}
+}
+
+func TestOrderMgtServer_UpdateOrderStatus(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	for _, id := range []string{"201", "202", "203"} {
+		if _, err := client.AddOrder(ctx, &pb.Order{Id: id, Items: []string{"Apple Watch S4"}, Destination: "San Jose, CA", Price: amount.New("USD", 400, 0)}); err != nil {
+			t.Fatalf("AddOrder(%s) error: %v", id, err)
+		}
+	}
+	order, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "201"})
+	if err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+
+	// Only ProcessOrders moves the order forward, the clients can't skip the shipment.
+	for _, to := range []pb.OrderStatus{pb.OrderStatus_PROCESSING, pb.OrderStatus_SHIPPED, pb.OrderStatus_DELIVERED} {
+		changed := *order
+		changed.Status = to
+		stream, err := client.UpdateOrders(ctx)
+		if err != nil {
+			t.Fatalf("UpdateOrders() error: %v", err)
+		}
+		if err := stream.Send(&changed); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+		res, err := stream.CloseAndRecv()
+		if err != nil {
+			t.Fatalf("CloseAndRecv() error: %v", err)
+		}
+		if code := codes.Code(res.Results[0].Status.Code); code != codes.InvalidArgument {
+			t.Errorf("UpdateOrders() to %s got %s, want InvalidArgument", to, code)
+		}
+
+		if _, err := client.UpdateOrder(ctx, &pb.UpdateOrderRequest{Order: &changed}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("UpdateOrder() to %s got %v, want InvalidArgument", to, err)
+		}
+	}
+	if got, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "201"}); err != nil || got.Status != pb.OrderStatus_CREATED {
+		t.Errorf("GetOrder() = %v, %v, want CREATED", got, err)
+	}
+
+	// The orders can still be cancelled by an update while the lifecycle allows it.
+	cancelled := *order
+	cancelled.Id, cancelled.Status = "202", pb.OrderStatus_CANCELLED
+	if got, err := client.UpdateOrder(ctx, &pb.UpdateOrderRequest{Order: &cancelled}); err != nil || got.Status != pb.OrderStatus_CANCELLED {
+		t.Errorf("UpdateOrder() to CANCELLED = %v, %v, want CANCELLED", got, err)
+	}
+	_, err = client.UpdateOrder(ctx, &pb.UpdateOrderRequest{Order: &pb.Order{Id: "203", Status: pb.OrderStatus_CANCELLED}, UpdateMask: &field_mask.FieldMask{Paths: []string{"status"}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateOrder() with status mask got %v, want InvalidArgument", err)
+	}
+}
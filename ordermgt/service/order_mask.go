@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "ordergmt/service/ecommerce"
+)
+
+// The fields of Order which can be updated, by their paths in the update mask.
+// The order ID identifies the order and the version is maintained by the server, they can't be updated.
+// The status is moved by ProcessOrders and CancelOrder, it can't be updated either.
+var orderMaskFields = map[string]func(dst, src *pb.Order){
+	"items":       func(dst, src *pb.Order) { dst.Items = src.Items },
+	"description": func(dst, src *pb.Order) { dst.Description = src.Description },
+	"price":       func(dst, src *pb.Order) { dst.Price = src.Price },
+	"destination": func(dst, src *pb.Order) { dst.Destination = src.Destination },
+}
+
+// Copy the fields in the update mask from src to dst, all the fields are copied if the mask is empty.
+// Returns an InvalidArgument error with the BadRequest details listing the unknown paths, nothing is copied in this case.
+func applyOrderMask(dst, src *pb.Order, mask *field_mask.FieldMask) error {
+	if len(mask.GetPaths()) == 0 {
+		for _, copyField := range orderMaskFields {
+			copyField(dst, src)
+		}
+		return nil
+	}
+
+	var violations []*epb.BadRequest_FieldViolation
+	for i, path := range mask.Paths {
+		if _, ok := orderMaskFields[path]; !ok {
+			violations = append(violations, &epb.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("updateMask.paths[%d]", i),
+				Description: fmt.Sprintf("unknown or immutable field %q", path),
+			})
+		}
+	}
+	if len(violations) > 0 {
+		errorStatus := status.New(codes.InvalidArgument, "Invalid update mask")
+		ds, err := errorStatus.WithDetails(&epb.BadRequest{FieldViolations: violations})
+		if err != nil {
+			return errorStatus.Err()
+		}
+		return ds.Err()
+	}
+
+	for _, path := range mask.Paths {
+		orderMaskFields[path](dst, src)
+	}
+	return nil
+}
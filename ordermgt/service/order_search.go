@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	money "google.golang.org/genproto/googleapis/type/money"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Find the orders matching the search request with the order ID after the given one, sorted by order ID.
+// With a positive limit, only the first limit orders are returned, so at most limit orders are buffered.
+// If the request has the items keywords, the candidates are looked up from the item index in the order of order ID,
+// and the lookup stops once the limit is reached.
+// Otherwise all the orders in the store are scanned, the store ranges in no particular order,
+// so only the orders before the last buffered one are kept once the limit is reached.
+// The search stops with a Canceled or DeadlineExceeded error as soon as the context is done.
+func (s *orderMgtServer) findOrders(ctx context.Context, req *pb.SearchOrdersRequest, after string, limit int) ([]*pb.Order, error) {
+	var matches []*pb.Order
+	tokens := tokenize(req.Items)
+	if len(tokens) > 0 {
+		for _, id := range s.index.lookup(tokens) {
+			if limit > 0 && len(matches) == limit {
+				break
+			}
+			if ctx.Err() != nil {
+				return nil, contextError(ctx)
+			}
+			if id <= after {
+				continue
+			}
+			order, err := s.store.Get(id)
+			if err == errOrderNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			// The order may be changed after the lookup, so check it again.
+			if matchOrder(order, req) {
+				matches = append(matches, order)
+			}
+		}
+		return matches, nil
+	}
+
+	err := s.store.Range(func(order *pb.Order) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if order.Id <= after || (limit > 0 && len(matches) == limit && order.Id > matches[limit-1].Id) || !matchOrder(order, req) {
+			return true
+		}
+		// Insert the order in the order of order ID, and drop the last one over the limit.
+		i := sort.Search(len(matches), func(i int) bool { return matches[i].Id > order.Id })
+		matches = append(matches, nil)
+		copy(matches[i+1:], matches[i:])
+		matches[i] = order
+		if limit > 0 && len(matches) > limit {
+			matches = matches[:limit]
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+	return matches, nil
+}
+
+// Check whether the order meets all the filters in the search request.
+func matchOrder(order *pb.Order, req *pb.SearchOrdersRequest) bool {
+	if tokens := tokenize(req.Items); len(tokens) > 0 {
+		orderTokens := make(map[string]bool)
+		for _, item := range order.Items {
+			for _, token := range tokenize(item) {
+				orderTokens[token] = true
+			}
+		}
+		for _, token := range tokens {
+			if !orderTokens[token] {
+				return false
+			}
+		}
+	}
+	if req.Destination != "" && !containsFold(order.Destination, req.Destination) {
+		return false
+	}
+	if req.Description != "" && !containsFold(order.Description, req.Description) {
+		return false
+	}
+	if req.MinPrice != nil && !(sameCurrency(order.Price, req.MinPrice) && amount.Compare(order.Price, req.MinPrice) >= 0) {
+		return false
+	}
+	if req.MaxPrice != nil && !(sameCurrency(order.Price, req.MaxPrice) && amount.Compare(order.Price, req.MaxPrice) <= 0) {
+		return false
+	}
+	return true
+}
+
+// Case-insensitive version of strings.Contains.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// Check the order's price is in the currency of the bound.
+func sameCurrency(price, bound *money.Money) bool {
+	return price.GetCurrencyCode() == bound.CurrencyCode
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	money "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Find the shipments matching the request, sorted by the creation time, then the shipment ID.
+// The search stops with a Canceled or DeadlineExceeded error as soon as the context is done.
+func (s *orderMgtServer) findShipments(ctx context.Context, req *pb.ListShipmentsRequest) ([]*pb.CombinedShipment, error) {
+	var matches []*pb.CombinedShipment
+	err := s.shipments.Range(func(shipment *pb.CombinedShipment) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if matchShipment(shipment, req) {
+			matches = append(matches, shipment)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return shipmentBefore(matches[i].CreateTime, matches[i].Id, matches[j].CreateTime, matches[j].Id)
+	})
+	return matches, nil
+}
+
+// Check whether the shipment created at aTime with the ID aId is listed before the one created at bTime with the ID bId.
+func shipmentBefore(aTime *timestamp.Timestamp, aId string, bTime *timestamp.Timestamp, bId string) bool {
+	switch {
+	case timestampBefore(aTime, bTime):
+		return true
+	case timestampBefore(bTime, aTime):
+		return false
+	}
+	return aId < bId
+}
+
+// Format the position of the shipment in the list (the creation time, then the shipment ID) as the key of the page token.
+func shipmentPageKey(shipment *pb.CombinedShipment) string {
+	return fmt.Sprintf("%d.%09d/%s", shipment.CreateTime.GetSeconds(), shipment.CreateTime.GetNanos(), shipment.Id)
+}
+
+// Parse the position of the last returned shipment from the key of the page token.
+func parseShipmentPageKey(key string) (*timestamp.Timestamp, string, error) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return nil, "", fmt.Errorf("invalid shipment key %q", key)
+	}
+	times := strings.SplitN(parts[0], ".", 2)
+	if len(times) != 2 {
+		return nil, "", fmt.Errorf("invalid shipment key %q", key)
+	}
+	seconds, err := strconv.ParseInt(times[0], 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid shipment key %q", key)
+	}
+	nanos, err := strconv.ParseInt(times[1], 10, 32)
+	if err != nil || nanos < 0 || nanos >= 1e9 {
+		return nil, "", fmt.Errorf("invalid shipment key %q", key)
+	}
+	return &timestamp.Timestamp{Seconds: seconds, Nanos: int32(nanos)}, parts[1], nil
+}
+
+// Check whether the shipment matches all the filters in the request.
+func matchShipment(shipment *pb.CombinedShipment, req *pb.ListShipmentsRequest) bool {
+	if req.Destination != "" && !containsFold(shipment.Destination, req.Destination) {
+		return false
+	}
+	if len(req.Statuses) > 0 {
+		found := false
+		for _, st := range req.Statuses {
+			if shipment.Status == st {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if req.CreatedAfter != nil && timestampBefore(shipment.CreateTime, req.CreatedAfter) {
+		return false
+	}
+	if req.CreatedBefore != nil && !timestampBefore(shipment.CreateTime, req.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// Check whether the timestamp a is before b.
+func timestampBefore(a, b *timestamp.Timestamp) bool {
+	if a.GetSeconds() != b.GetSeconds() {
+		return a.GetSeconds() < b.GetSeconds()
+	}
+	return a.GetNanos() < b.GetNanos()
+}
+
+// rejectedOrder is an order of a combined shipment which can't be shipped, with the reason.
+type rejectedOrder struct {
+	orderId string
+	err     error
+}
+
+// Ship the orders of the combined shipment built by ProcessOrders.
+// The shipment is stored before its orders are marked SHIPPED, so a shipped order always has its shipment,
+// and the orders are locked between the two writes, so they can't be changed (e.g. cancelled) meanwhile.
+// Returns the stored shipment of the orders which can be shipped (nil if there isn't any), and the orders which can't be shipped.
+// Returns an Internal error if the shipment can't be stored, then none of the orders is shipped.
+// If the shipment is stored but some orders can't be marked SHIPPED, the shipment is returned with an Internal error,
+// and those orders are marked SHIPPED by recoverProcessingOrders on the next start.
+func (s *orderMgtServer) shipOrders(comb *pb.CombinedShipment) (*pb.CombinedShipment, []rejectedOrder, error) {
+	ids := make([]string, len(comb.OrdersList))
+	for i, order := range comb.OrdersList {
+		ids[i] = order.Id
+	}
+	unlock := s.lockOrders(ids)
+	defer unlock()
+
+	var shipped []*pb.Order
+	var rejected []rejectedOrder
+	for _, id := range ids {
+		order, err := s.store.Get(id)
+		if err == errOrderNotFound {
+			rejected = append(rejected, rejectedOrder{id, status.Errorf(codes.NotFound, "Order does not exist : %s", id)})
+			continue
+		}
+		if err != nil {
+			return nil, nil, status.Errorf(codes.Internal, "Failed to load order %s: %v", id, err)
+		}
+		if err := transitionOrder(order, pb.OrderStatus_SHIPPED); err != nil {
+			rejected = append(rejected, rejectedOrder{id, err})
+			continue
+		}
+		// The order as it will be stored by updateLockedOrder.
+		order.Version++
+		shipped = append(shipped, order)
+	}
+	if len(shipped) == 0 {
+		return nil, rejected, nil
+	}
+
+	shipment := proto.Clone(comb).(*pb.CombinedShipment)
+	shipment.OrdersList = shipped
+	shipment.Status = pb.OrderStatus_SHIPPED
+	if err := summarizeShipment(shipment); err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to total shipment %s: %v", shipment.Id, err)
+	}
+	if err := s.shipments.Put(shipment); err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "Failed to store shipment %s: %v", shipment.Id, err)
+	}
+	for _, order := range shipped {
+		_, err := s.updateLockedOrder(order.Id, func(current *pb.Order) (*pb.Order, error) {
+			return current, transitionOrder(current, pb.OrderStatus_SHIPPED)
+		})
+		if err != nil {
+			return shipment, nil, status.Errorf(codes.Internal, "Shipment %s is stored, but order %s can't be marked %s: %v", shipment.Id, order.Id, pb.OrderStatus_SHIPPED, err)
+		}
+	}
+	return shipment, rejected, nil
+}
+
+// Recover the orders left in PROCESSING when the process stopped in the middle of ProcessOrders.
+// The order in a stored shipment is marked SHIPPED, the other orders are moved back to CREATED, so they can be processed again.
+// Must be called before serving, when no order is being processed.
+// Returns the number of the recovered orders.
+func (s *orderMgtServer) recoverProcessingOrders() (int, error) {
+	var ids []string
+	err := s.store.Range(func(order *pb.Order) bool {
+		if order.Status == pb.OrderStatus_PROCESSING {
+			ids = append(ids, order.Id)
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		_, err := s.shipments.GetForOrder(id)
+		switch {
+		case err == errShipmentNotFound:
+			err = s.releaseProcessingOrder(id)
+		case err == nil:
+			_, err = s.updateOrder(id, func(current *pb.Order) (*pb.Order, error) {
+				return current, transitionOrder(current, pb.OrderStatus_SHIPPED)
+			})
+		}
+		if err != nil {
+			return 0, fmt.Errorf("order %s: %v", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+// Generate a random shipment ID, e.g. "cmb-9f86d081884c7d65".
+// The IDs are unique across the batches, the streams and the server restarts.
+func newShipmentId() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "cmb-" + hex.EncodeToString(b), nil
+}
+
+// Fill the order count, the item count and the total price of the shipment from its orders.
+// The total price is summed up exactly per currency.
+// Returns an error if an order has no price.
+func summarizeShipment(shipment *pb.CombinedShipment) error {
+	var itemCount int32
+	var totalPrice []*money.Money
+	for _, order := range shipment.OrdersList {
+		if order.Price == nil {
+			return fmt.Errorf("order %s has no price", order.Id)
+		}
+		itemCount += int32(len(order.Items))
+		total, err := amount.AddToTotals(totalPrice, order.Price)
+		if err != nil {
+			return fmt.Errorf("order %s: %v", order.Id, err)
+		}
+		totalPrice = total
+	}
+	shipment.OrderCount = int32(len(shipment.OrdersList))
+	shipment.ItemCount = itemCount
+	shipment.TotalPrice = totalPrice
+	return nil
+}
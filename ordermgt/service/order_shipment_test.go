@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Process the orders in one ProcessOrders stream and return the combined shipments.
+func processOrders(t *testing.T, ctx context.Context, client pb.OrderManagementClient, orderIds ...string) []*pb.CombinedShipment {
+	t.Helper()
+	stream, err := client.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+	for _, id := range orderIds {
+		if err := stream.Send(&wrapper.StringValue{Value: id}); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+	}
+	stream.CloseSend()
+	var shipments []*pb.CombinedShipment
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return shipments
+		}
+		if err != nil {
+			t.Fatalf("Recv() error: %v", err)
+		}
+		if shipment := res.GetShipment(); shipment != nil {
+			shipments = append(shipments, shipment)
+		}
+	}
+}
+
+func TestOrderMgtServer_GetShipment(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	for _, order := range []*pb.Order{
+		{Id: "101", Items: []string{"Google Home Mini", "Google Nest Hub"}, Destination: "Mountain View ,CA", Price: amount.New("USD", 400, 0)},
+		{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "Mountain View ,CA", Price: amount.New("USD", 550, 500000000)},
+		{Id: "103", Items: []string{"Amazon Echo"}, Destination: "Mountain View ,CA", Price: amount.New("USD", 30, 0)},
+	} {
+		if _, err := client.AddOrder(ctx, order); err != nil {
+			t.Fatalf("AddOrder() error: %v", err)
+		}
+	}
+
+	// The shipments to the same destination in different streams must have different IDs.
+	first := processOrders(t, ctx, client, "101", "102")
+	second := processOrders(t, ctx, client, "103")
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("ProcessOrders() got %d and %d shipments, want 1 and 1", len(first), len(second))
+	}
+	if first[0].Id == second[0].Id {
+		t.Errorf("ProcessOrders() got the same shipment ID %s in different streams", first[0].Id)
+	}
+
+	shipment := first[0]
+	if shipment.OrderCount != 2 || shipment.ItemCount != 3 {
+		t.Errorf("shipment counts = %d orders, %d items, want 2 orders, 3 items", shipment.OrderCount, shipment.ItemCount)
+	}
+	if len(shipment.TotalPrice) != 1 || amount.Format(shipment.TotalPrice[0]) != "950.50 USD" {
+		t.Errorf("shipment total price = %v, want 950.50 USD", shipment.TotalPrice)
+	}
+	if shipment.Destination != "Mountain View, CA" {
+		t.Errorf("shipment destination = %q, want %q", shipment.Destination, "Mountain View, CA")
+	}
+	if shipment.CreateTime == nil {
+		t.Errorf("shipment create time is not set")
+	}
+
+	got, err := client.GetShipment(ctx, &wrapper.StringValue{Value: shipment.Id})
+	if err != nil {
+		t.Fatalf("GetShipment() error: %v", err)
+	}
+	if !proto.Equal(got, shipment) {
+		t.Errorf("GetShipment() = %v, want %v", got, shipment)
+	}
+	if _, err := client.GetShipment(ctx, &wrapper.StringValue{Value: "cmb-unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetShipment() on unknown shipment got %v, want NotFound", err)
+	}
+}
+
+func TestOrderMgtServer_ListShipments(t *testing.T) {
+	srv := newTestOrderMgtServer(t, newMemoryOrderStore())
+	for i, shipment := range []*pb.CombinedShipment{
+		{Id: "cmb-a", Destination: "San Jose, CA", Status: pb.OrderStatus_PROCESSING, OrdersList: []*pb.Order{{Id: "101"}}},
+		{Id: "cmb-b", Destination: "Mountain View, CA", Status: pb.OrderStatus_SHIPPED, OrdersList: []*pb.Order{{Id: "102"}}},
+		{Id: "cmb-c", Destination: "San Jose, CA", Status: pb.OrderStatus_SHIPPED, OrdersList: []*pb.Order{{Id: "103"}}},
+		{Id: "cmb-d", Destination: "San Jose, CA", Status: pb.OrderStatus_DELIVERED, OrdersList: []*pb.Order{{Id: "104"}}},
+	} {
+		shipment.CreateTime = &timestamp.Timestamp{Seconds: int64(1000 + i)}
+		if err := srv.shipments.Put(shipment); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	client, stop := startOrderMgtServer(t, srv)
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// Collect the shipment IDs of all the pages.
+	list := func(req *pb.ListShipmentsRequest) []string {
+		t.Helper()
+		var ids []string
+		for {
+			res, err := client.ListShipments(ctx, req)
+			if err != nil {
+				t.Fatalf("ListShipments() error: %v", err)
+			}
+			for _, shipment := range res.Shipments {
+				ids = append(ids, shipment.Id)
+			}
+			if res.NextPageToken == "" {
+				return ids
+			}
+			req.PageToken = res.NextPageToken
+		}
+	}
+
+	tests := []struct {
+		name string
+		req  *pb.ListShipmentsRequest
+		want string
+	}{
+		{"all", &pb.ListShipmentsRequest{}, "[cmb-a cmb-b cmb-c cmb-d]"},
+		{"paged", &pb.ListShipmentsRequest{PageSize: 1}, "[cmb-a cmb-b cmb-c cmb-d]"},
+		{"destination", &pb.ListShipmentsRequest{Destination: "san jose"}, "[cmb-a cmb-c cmb-d]"},
+		{"statuses", &pb.ListShipmentsRequest{Statuses: []pb.OrderStatus{pb.OrderStatus_SHIPPED, pb.OrderStatus_DELIVERED}}, "[cmb-b cmb-c cmb-d]"},
+		{"time range", &pb.ListShipmentsRequest{CreatedAfter: &timestamp.Timestamp{Seconds: 1001}, CreatedBefore: &timestamp.Timestamp{Seconds: 1003}}, "[cmb-b cmb-c]"},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprint(list(test.req)); got != test.want {
+			t.Errorf("ListShipments(%s) = %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	for _, token := range []string{"!", encodeKeyPageToken("cmb-a"), encodeKeyPageToken("1000.x/cmb-a")} {
+		if _, err := client.ListShipments(ctx, &pb.ListShipmentsRequest{PageToken: token}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListShipments() with invalid page token %q got %v, want InvalidArgument", token, err)
+		}
+	}
+
+	// The shipments stored between the pages, even before the last returned one, don't make the pages skip or repeat.
+	res, err := client.ListShipments(ctx, &pb.ListShipmentsRequest{PageSize: 2})
+	if err != nil {
+		t.Fatalf("ListShipments() error: %v", err)
+	}
+	for _, shipment := range []*pb.CombinedShipment{
+		{Id: "cmb-0", CreateTime: &timestamp.Timestamp{Seconds: 999}, OrdersList: []*pb.Order{{Id: "100"}}},
+		{Id: "cmb-bb", CreateTime: &timestamp.Timestamp{Seconds: 1001}, OrdersList: []*pb.Order{{Id: "107"}}},
+	} {
+		if err := srv.shipments.Put(shipment); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	if got := fmt.Sprint(list(&pb.ListShipmentsRequest{PageSize: 2, PageToken: res.NextPageToken})); got != "[cmb-bb cmb-c cmb-d]" {
+		t.Errorf("ListShipments() after the first page = %s, want [cmb-bb cmb-c cmb-d]", got)
+	}
+
+	got, err := client.GetShipmentForOrder(ctx, &wrapper.StringValue{Value: "103"})
+	if err != nil {
+		t.Fatalf("GetShipmentForOrder() error: %v", err)
+	}
+	if got.Id != "cmb-c" {
+		t.Errorf("GetShipmentForOrder(103) = %s, want cmb-c", got.Id)
+	}
+	if _, err := client.GetShipmentForOrder(ctx, &wrapper.StringValue{Value: "105"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetShipmentForOrder() on unshipped order got %v, want NotFound", err)
+	}
+}
+
+func TestOrderMgtServer_ShipmentTotalPrice(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// 0.10 can't be represented exactly in float, but the total must be exactly 0.30 USD.
+	orders := []*pb.Order{
+		{Id: "301", Items: []string{"Sticker"}, Destination: "San Jose, CA", Price: amount.New("USD", 0, 100000000)},
+		{Id: "302", Items: []string{"Sticker"}, Destination: "San Jose, CA", Price: amount.New("USD", 0, 100000000)},
+		{Id: "303", Items: []string{"Sticker"}, Destination: "San Jose, CA", Price: amount.New("USD", 0, 100000000)},
+	}
+	stream, err := client.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+	for _, order := range orders {
+		if _, err := client.AddOrder(ctx, order); err != nil {
+			t.Fatalf("AddOrder() error: %v", err)
+		}
+		if err := stream.Send(&wrapper.StringValue{Value: order.Id}); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+	}
+	stream.CloseSend()
+
+	var totals []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error: %v", err)
+		}
+		for _, total := range res.GetShipment().GetTotalPrice() {
+			totals = append(totals, amount.Format(total))
+		}
+	}
+	if want := []string{"0.30 USD"}; !reflect.DeepEqual(totals, want) {
+		t.Errorf("shipment total price = %v, want %v", totals, want)
+	}
+}
+
+// failingShipmentStore fails to store any shipment.
+type failingShipmentStore struct {
+	ShipmentStore
+}
+
+func (failingShipmentStore) Put(shipment *pb.CombinedShipment) error {
+	return fmt.Errorf("disk full")
+}
+
+// The orders aren't marked SHIPPED if their shipment can't be stored, they are moved back to CREATED.
+func TestOrderMgtServer_ProcessOrdersShipmentNotStored(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	srv := newTestOrderMgtServer(t, store)
+	srv.shipments = failingShipmentStore{newMemoryShipmentStore()}
+	client, stop := startOrderMgtServer(t, srv)
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	stream, err := client.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+	for _, id := range []string{"102", "104"} {
+		if err := stream.Send(&wrapper.StringValue{Value: id}); err != nil {
+			t.Fatalf("Send(%s) error: %v", id, err)
+		}
+	}
+	stream.CloseSend()
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Fatalf("Recv() got %v, want Internal", err)
+	}
+	// The stream is finished on the server before the client gets the error, so the orders have been released.
+	for _, id := range []string{"102", "104"} {
+		if order, err := client.GetOrder(ctx, &wrapper.StringValue{Value: id}); err != nil || order.Status != pb.OrderStatus_CREATED {
+			t.Errorf("GetOrder(%s) = %v, %v, want CREATED", id, order, err)
+		}
+	}
+}
+
+func TestOrderMgtServer_RecoverProcessingOrders(t *testing.T) {
+	store := newMemoryOrderStore()
+	for _, order := range []*pb.Order{
+		{Id: "101", Status: pb.OrderStatus_PROCESSING, Price: amount.New("USD", 100, 0)},
+		{Id: "102", Status: pb.OrderStatus_PROCESSING, Price: amount.New("USD", 200, 0)},
+		{Id: "103", Status: pb.OrderStatus_CREATED, Price: amount.New("USD", 300, 0)},
+	} {
+		if err := store.Put(order); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	srv := newTestOrderMgtServer(t, store)
+	// The process stopped after the shipment of 101 was stored, and before 102 was shipped.
+	if err := srv.shipments.Put(&pb.CombinedShipment{Id: "cmb-a", OrdersList: []*pb.Order{{Id: "101"}}}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	if n, err := srv.recoverProcessingOrders(); err != nil || n != 2 {
+		t.Fatalf("recoverProcessingOrders() = %d, %v, want 2", n, err)
+	}
+	for id, want := range map[string]pb.OrderStatus{"101": pb.OrderStatus_SHIPPED, "102": pb.OrderStatus_CREATED, "103": pb.OrderStatus_CREATED} {
+		if order, err := store.Get(id); err != nil || order.Status != want {
+			t.Errorf("order %s = %v, %v, want %v", id, order, err, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	pb "ordergmt/service/ecommerce"
+)
+
+// The number of the shards in memoryOrderStore.
+const orderStoreShardCount = 32
+
+var errOrderNotFound = errors.New("order not found")
+
+// OrderStore is the storage of the orders used by orderMgtServer.
+// The orders passed in and returned are copies, so the caller can modify them without affecting the store.
+type OrderStore interface {
+	// Get an order by order ID, returns errOrderNotFound if the order does not exist.
+	Get(id string) (*pb.Order, error)
+	// Put an order, the existing order with the same order ID will be replaced.
+	Put(order *pb.Order) error
+	// Update atomically replaces the order by the one returned from fn, and returns the stored order.
+	// fn receives a copy of the current order, or nil if the order does not exist.
+	// If fn returns an error, the store is not changed and the error is returned.
+	Update(id string, fn func(order *pb.Order) (*pb.Order, error)) (*pb.Order, error)
+	// Range calls f on each order in the store until f returns false.
+	Range(f func(order *pb.Order) bool) error
+	// Len returns the number of orders in the store.
+	Len() int
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// memoryOrderStore keeps the orders in memory, all the orders will be lost when the process exits.
+// The orders are spread over a fixed number of shards by the hash of the order ID,
+// each shard has its own lock, so the concurrent RPCs on different orders rarely block each other.
+type memoryOrderStore struct {
+	shards [orderStoreShardCount]orderStoreShard
+}
+
+// orderStoreShard is a part of the orders in memoryOrderStore.
+type orderStoreShard struct {
+	mu     sync.RWMutex
+	orders map[string]*pb.Order
+}
+
+// Create an empty in-memory order store.
+func newMemoryOrderStore() *memoryOrderStore {
+	m := &memoryOrderStore{}
+	for i := range m.shards {
+		m.shards[i].orders = make(map[string]*pb.Order)
+	}
+	return m
+}
+
+// Get the shard which the order ID belongs to.
+func (m *memoryOrderStore) shard(id string) *orderStoreShard {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return &m.shards[h.Sum32()%orderStoreShardCount]
+}
+
+func (m *memoryOrderStore) Get(id string) (*pb.Order, error) {
+	shard := m.shard(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	order, exists := shard.orders[id]
+	if !exists {
+		return nil, errOrderNotFound
+	}
+	return proto.Clone(order).(*pb.Order), nil
+}
+
+func (m *memoryOrderStore) Put(order *pb.Order) error {
+	order = proto.Clone(order).(*pb.Order)
+	shard := m.shard(order.Id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.orders[order.Id] = order
+	return nil
+}
+
+func (m *memoryOrderStore) Update(id string, fn func(order *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	shard := m.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	var current *pb.Order
+	if order, exists := shard.orders[id]; exists {
+		current = proto.Clone(order).(*pb.Order)
+	}
+	updated, err := fn(current)
+	if err != nil {
+		return nil, err
+	}
+	shard.orders[id] = proto.Clone(updated).(*pb.Order)
+	return updated, nil
+}
+
+// Range takes a snapshot of each shard and calls f without holding the lock,
+// so f is free to call the other methods of the store.
+func (m *memoryOrderStore) Range(f func(order *pb.Order) bool) error {
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		orders := make([]*pb.Order, 0, len(shard.orders))
+		for _, order := range shard.orders {
+			orders = append(orders, proto.Clone(order).(*pb.Order))
+		}
+		shard.mu.RUnlock()
+
+		for _, order := range orders {
+			if !f(order) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (m *memoryOrderStore) Len() int {
+	n := 0
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		n += len(shard.orders)
+		shard.mu.RUnlock()
+	}
+	return n
+}
+
+func (m *memoryOrderStore) Close() error {
+	return nil
+}
+
+// fileOrderStore persists the orders into an append-only log file.
+// Every Put appends the whole order as a new record, the latest record of an order wins when the log is replayed.
+// All the orders are also kept in memory for serving the reads.
+// The appends are serialized, so the order of the records in the log is the same as the order of the updates in memory.
+type fileOrderStore struct {
+	*memoryOrderStore
+	mu  sync.Mutex // Serializes the appends to the log file.
+	log *recordLog // The records of the marshalled orders.
+}
+
+// Open the order log at path (create it if it doesn't exist) and load all the orders in it.
+// A broken record at the end of the log (caused by a crash in the middle of a write) will be truncated.
+func openFileOrderStore(path string) (*fileOrderStore, error) {
+	s := &fileOrderStore{memoryOrderStore: newMemoryOrderStore()}
+	log, err := openRecordLog(path, func(data []byte, offset int64) error {
+		order := &pb.Order{}
+		if err := proto.Unmarshal(data, order); err != nil {
+			return fmt.Errorf("corrupted order record at offset %d: %v", offset, err)
+		}
+		return s.memoryOrderStore.Put(order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.log = log
+	return s, nil
+}
+
+func (s *fileOrderStore) Put(order *pb.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(order)
+}
+
+// All the writes hold the lock of the log file, so the order can't be changed by others between fn and the write.
+func (s *fileOrderStore) Update(id string, fn func(order *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, err := s.memoryOrderStore.Get(id)
+	if err == errOrderNotFound {
+		current = nil
+	} else if err != nil {
+		return nil, err
+	}
+	updated, err := fn(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.put(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Append the order to the log and put it in memory, the caller must hold the lock.
+func (s *fileOrderStore) put(order *pb.Order) error {
+	data, err := proto.Marshal(order)
+	if err != nil {
+		return err
+	}
+	if err := s.log.append(data); err != nil {
+		return err
+	}
+	return s.memoryOrderStore.Put(order)
+}
+
+func (s *fileOrderStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
+
+// Create the order store by the store type.
+func newOrderStore(storeType string, path string) (OrderStore, error) {
+	switch storeType {
+	case "memory":
+		return newMemoryOrderStore(), nil
+	case "file":
+		return openFileOrderStore(path)
+	default:
+		return nil, fmt.Errorf("unknown order store type: %s", storeType)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Create a temporary directory for the test, the returned function removes it.
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "ordermgt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// Run the test function against every type of the order store.
+func forEachOrderStore(t *testing.T, test func(t *testing.T, store OrderStore)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newMemoryOrderStore())
+	})
+	t.Run("file", func(t *testing.T) {
+		dir, cleanup := tempDir(t)
+		defer cleanup()
+		store, err := openFileOrderStore(filepath.Join(dir, "orders.log"))
+		if err != nil {
+			t.Fatalf("openFileOrderStore() error: %v", err)
+		}
+		defer store.Close()
+		test(t, store)
+	})
+}
+
+func TestOrderStore_PutGetRange(t *testing.T) {
+	forEachOrderStore(t, func(t *testing.T, store OrderStore) {
+		if _, err := store.Get("101"); err != errOrderNotFound {
+			t.Fatalf("Get() on empty store error = %v, want %v", err, errOrderNotFound)
+		}
+
+		order := &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)}
+		if err := store.Put(order); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+		// Modifying the order after Put must not affect the stored one.
+		order.Price = amount.New("USD", 1, 0)
+		if err := store.Put(&pb.Order{Id: "102", Items: []string{"Mac Book Pro"}, Destination: "Mountain View, CA"}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+
+		got, err := store.Get("101")
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		if got.Price.GetUnits() != 1300 {
+			t.Errorf("Get() price = %v, want 1300", got.Price)
+		}
+		if store.Len() != 2 {
+			t.Errorf("Len() = %d, want 2", store.Len())
+		}
+
+		var ids []string
+		if err := store.Range(func(order *pb.Order) bool {
+			ids = append(ids, order.Id)
+			return true
+		}); err != nil {
+			t.Fatalf("Range() error: %v", err)
+		}
+		sort.Strings(ids)
+		if len(ids) != 2 || ids[0] != "101" || ids[1] != "102" {
+			t.Errorf("Range() ids = %v, want [101 102]", ids)
+		}
+	})
+}
+
+func TestFileOrderStore_Reopen(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "orders.log")
+	store, err := openFileOrderStore(path)
+	if err != nil {
+		t.Fatalf("openFileOrderStore() error: %v", err)
+	}
+	want := &pb.Order{Id: "103", Items: []string{"Apple Watch S4", "iPad Pro"}, Destination: "San Jose, CA", Price: amount.New("USD", 2800, 0)}
+	for _, order := range []*pb.Order{{Id: "103", Items: []string{"Apple Watch S4"}}, want} {
+		if err := store.Put(order); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	store.Close()
+
+	// Simulate a crash in the middle of a write by appending a broken record.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{0, 0, 0, 42, 1, 2})
+	file.Close()
+
+	store, err = openFileOrderStore(path)
+	if err != nil {
+		t.Fatalf("openFileOrderStore() error: %v", err)
+	}
+	got, err := store.Get("103")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("Get() after reopen = %v, want %v", got, want)
+	}
+
+	// The broken record should be truncated, so the new records can be appended and loaded.
+	if err := store.Put(&pb.Order{Id: "104"}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	store.Close()
+	store, err = openFileOrderStore(path)
+	if err != nil {
+		t.Fatalf("openFileOrderStore() error: %v", err)
+	}
+	defer store.Close()
+	if store.Len() != 2 {
+		t.Errorf("Len() after reopen = %d, want 2", store.Len())
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Hammer AddOrder and UpdateOrders from many goroutines at the same time.
+// Run with "go test -race" to let the race detector check the order stores.
+func TestOrderMgtServer_ConcurrentAddAndUpdateOrders(t *testing.T) {
+	const (
+		workers         = 16
+		ordersPerWorker = 50
+	)
+	forEachOrderStore(t, func(t *testing.T, store OrderStore) {
+		client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store))
+		defer stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*2)
+		for w := 0; w < workers; w++ {
+			wg.Add(2)
+			// Add new orders.
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < ordersPerWorker; i++ {
+					order := &pb.Order{Id: fmt.Sprintf("%d-%d", w, i), Items: []string{"Amazon Echo"}, Destination: "San Jose, CA", Price: amount.New("USD", 30, 0)}
+					if _, err := client.AddOrder(ctx, order); err != nil {
+						errs <- fmt.Errorf("AddOrder(%s): %v", order.Id, err)
+						return
+					}
+				}
+			}(w)
+			// Update the orders shared by all the workers, so the same keys are written concurrently.
+			go func(w int) {
+				defer wg.Done()
+				stream, err := client.UpdateOrders(ctx)
+				if err != nil {
+					errs <- fmt.Errorf("UpdateOrders(): %v", err)
+					return
+				}
+				for i := 0; i < ordersPerWorker; i++ {
+					order := &pb.Order{Id: fmt.Sprintf("shared-%d", i%5), Items: []string{"Google Home Mini"}, Destination: "Mountain View, CA", Price: amount.New("USD", int64(w), 0)}
+					if err := stream.Send(order); err != nil {
+						errs <- fmt.Errorf("Send(%s): %v", order.Id, err)
+						return
+					}
+				}
+				if _, err := stream.CloseAndRecv(); err != nil {
+					errs <- fmt.Errorf("CloseAndRecv(): %v", err)
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+
+		if want := workers*ordersPerWorker + 5; store.Len() != want {
+			t.Errorf("Len() = %d, want %d", store.Len(), want)
+		}
+	})
+}
@@ -3,221 +3,819 @@ package main
 import (
 	"context"
 	"fmt"
-	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	wrapper "github.com/golang/protobuf/ptypes/wrappers"
 	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/idempotency"
+	"hash/fnv"
 	"io"
 	"log"
 	pb "ordergmt/service/ecommerce"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	maxBatchSize = 3
+	// The max number of orders which can be requested by one BatchGetOrders call.
+	maxBatchGetOrders = 1000
+
+	// The trailer key of the page token for resuming SearchOrders.
+	nextPageTokenKey = "next-page-token"
+
+	// The number of the locks for serializing the updates on the same order.
+	orderLockCount = 64
 )
 
-var orderMap = make(map[string]pb.Order)
+// The streaming methods which validate the received messages themselves, to report the invalid ones per message.
+var selfValidatingMethods = map[string]bool{
+	"/ecommerce.OrderManagement/addOrders":    true,
+	"/ecommerce.OrderManagement/updateOrders": true,
+}
 
 type orderMgtServer struct {
-	orderMap map[string]*pb.Order
+	store  OrderStore     // The storage of the orders.
+	index  *orderIndex    // The inverted index of the items for searching orders.
+	batch  batchConfig    // The default batch parameters of ProcessOrders.
+	events *orderEventLog // The latest order changes for WatchOrders.
+
+	idempotency *idempotency.Cache // The results of AddOrder by the idempotency keys.
+	shipments   ShipmentStore      // The combined shipments sent by ProcessOrders.
+
+	// Serializes the updates on the same order, so the events are recorded in the same order as the updates.
+	locks [orderLockCount]sync.Mutex
+}
+
+// Create an orderMgtServer on the order store, the item index will be built from the orders in the store.
+func newOrderMgtServer(store OrderStore) (*orderMgtServer, error) {
+	index, err := newOrderIndex(store)
+	if err != nil {
+		return nil, err
+	}
+	return &orderMgtServer{
+		store:  store,
+		index:  index,
+		batch:  batchConfig{size: defaultBatchSize, wait: defaultBatchWait, grouping: groupByExact},
+		events: newOrderEventLog(defaultEventLogSize),
+
+		idempotency: idempotency.NewCache(idempotency.DefaultTTL),
+		shipments:   newMemoryShipmentStore(),
+	}, nil
 }
 
 // Add a new order.
+// The order has been validated by the interceptor before reaching here.
+// The new order must be in CREATED status, an existing order can only be replaced before it is processed.
+// A retry with the same idempotency key in the metadata gets the original result without replacing the order again.
 // Simple RPC
 func (s *orderMgtServer) AddOrder(ctx context.Context, orderReq *pb.Order) (*wrapper.StringValue, error) {
-	if orderReq.Id == "-1" {
-		log.Printf("Order ID is invalid! -> Received Order ID %s", orderReq.Id)
-
-		errorStatus := status.New(codes.InvalidArgument, "Invalid information received")
-		ds, err := errorStatus.WithDetails(
-			&epb.BadRequest_FieldViolation{
-				Field:"ID",
-				Description: fmt.Sprintf("Order ID received is not valid %s : %s", orderReq.Id, orderReq.Description),
-			},
-		)
+	res, err := s.idempotency.Do(ctx, "AddOrder", orderReq, func() (proto.Message, error) {
+		if _, err := s.addOrder(orderReq); err != nil {
+			return nil, err
+		}
+		return &wrapper.StringValue{Value: "Order Added: " + orderReq.Id}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*wrapper.StringValue), nil
+}
+
+// Add multiple orders in one call.
+// All the orders will be sent from client as a stream, each order is validated and added in the same way as AddOrder.
+// The outcome of each order is returned in the response, an order which can't be added doesn't stop the other orders.
+// Client-side Streaming RPC
+func (s *orderMgtServer) AddOrders(stream pb.OrderManagement_AddOrdersServer) error {
+	res := &pb.AddOrdersResponse{}
+	for {
+		order, err := stream.Recv()
+		if err == io.EOF {
+			// Finished reading the order stream.
+			return stream.SendAndClose(res)
+		}
 		if err != nil {
-			return nil, errorStatus.Err()
+			return err
+		}
+		// Don't add the order once the client is gone or the deadline has passed.
+		if stream.Context().Err() != nil {
+			return contextError(stream.Context())
 		}
 
-		return nil, ds.Err()
-	} else {
-		log.Printf("Order Added. ID : %v", orderReq.Id)
-		orderMap[orderReq.Id] = *orderReq
-		return &wrapper.StringValue{Value: "Order Added: " + orderReq.Id}, nil
+		// The orders of this stream are not validated by the interceptor,
+		// so an invalid order is reported in its result instead of aborting the stream.
+		added, err := func() (*pb.Order, error) {
+			if err := validate(order); err != nil {
+				return nil, err
+			}
+			return s.addOrder(order)
+		}()
+		if err != nil {
+			st, _ := status.FromError(err)
+			if st.Code() == codes.Internal {
+				return err
+			}
+			log.Printf("Order ID : %s - %s", order.Id, st.Message())
+			res.Results = append(res.Results, &pb.AddOrderResult{OrderId: order.Id, Status: st.Proto()})
+			continue
+		}
+		res.Results = append(res.Results, &pb.AddOrderResult{OrderId: order.Id, Status: status.New(codes.OK, "").Proto(), Version: added.Version})
+	}
+}
+
+// Add or replace the order, an existing order can only be replaced before it is processed.
+func (s *orderMgtServer) addOrder(order *pb.Order) (*pb.Order, error) {
+	added, err := s.updateOrder(order.Id, func(current *pb.Order) (*pb.Order, error) {
+		// An existing order can only be replaced before it is processed.
+		if current != nil && current.Status != pb.OrderStatus_CREATED {
+			return nil, newPreconditionError(order.Id, fmt.Sprintf("Order %s already exists in %s", order.Id, current.Status))
+		}
+		if err := checkOrderUpdate(nil, order); err != nil {
+			return nil, err
+		}
+		return order, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("Order Added. ID : %v", order.Id)
+	return added, nil
 }
 
 // Get a order by order ID.
 // Simple RPC
 func (s *orderMgtServer) GetOrder(ctx context.Context, orderId *wrapper.StringValue) (*pb.Order, error) {
-	ord, exists := orderMap[orderId.Value]
-	if exists {
-		return &ord, status.New(codes.OK, "").Err()
+	ord, err := s.store.Get(orderId.Value)
+	if err == nil {
+		return ord, status.New(codes.OK, "").Err()
+	}
+	if err != errOrderNotFound {
+		return nil, status.Errorf(codes.Internal, "Failed to load order %s: %v", orderId.Value, err)
 	}
 
-	return nil, status.Errorf(codes.NotFound, "Order does not exist. : ", orderId)
+	return nil, status.Errorf(codes.NotFound, "Order does not exist. : %s", orderId.Value)
+}
+
+// Get multiple orders by order IDs in one call.
+// The found orders and the IDs which don't exist are returned separately, both in the order of the requested IDs.
+// Simple RPC
+func (s *orderMgtServer) BatchGetOrders(ctx context.Context, req *pb.BatchGetOrdersRequest) (*pb.BatchGetOrdersResponse, error) {
+	if len(req.OrderIds) > maxBatchGetOrders {
+		return nil, status.Errorf(codes.InvalidArgument, "At most %d orders can be requested at once, got %d", maxBatchGetOrders, len(req.OrderIds))
+	}
+	res := &pb.BatchGetOrdersResponse{}
+	for _, orderId := range req.OrderIds {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+		ord, err := s.store.Get(orderId)
+		if err == errOrderNotFound {
+			res.MissingIds = append(res.MissingIds, orderId)
+			continue
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to load order %s: %v", orderId, err)
+		}
+		res.Orders = append(res.Orders, ord)
+	}
+	return res, nil
 }
 
-// Get all the orders which has a certain item.
-// All the matched orders will be returned from orderMgtServer as a stream.
+// Search the orders by the filters in the request (items, destination, price range and description).
+// All the matched orders will be returned from orderMgtServer as a stream, sorted by order ID.
+// With maxResults, at most maxResults orders are returned, and the "next-page-token" trailer is set if more orders match,
+// which can be passed as the pageToken of the next search to resume after the last returned order.
+// The search stops as soon as the client cancels the call or the deadline passes.
 // Server-side Streaming RPC
-func (s *orderMgtServer) SearchOrders(searchQuery *wrappers.StringValue, stream pb.OrderManagement_SearchOrdersServer) error {
-	for key, order := range orderMap {
-		log.Print(key, order)
-		for _, itemStr := range order.Items {
-			log.Print(itemStr)
-			if strings.Contains(itemStr, searchQuery.Value) {
-				// Send the matching orders in a stream
-				err := stream.Send(&order)
-				if err != nil {
-					return fmt.Errorf("error sending message to stream : %v", err)
-				}
-				log.Print("Matching Order Found : " + key)
-				break
-			}
+func (s *orderMgtServer) SearchOrders(searchQuery *pb.SearchOrdersRequest, stream pb.OrderManagement_SearchOrdersServer) error {
+	ctx := stream.Context()
+	if searchQuery.MaxResults < 0 {
+		return status.Errorf(codes.InvalidArgument, "Max results must not be negative: %d", searchQuery.MaxResults)
+	}
+	after, err := decodeKeyPageToken(searchQuery.PageToken)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid page token: %s", searchQuery.PageToken)
+	}
+
+	// One more order than the max results is found to tell whether there is a next page.
+	max := int(searchQuery.MaxResults)
+	limit := 0
+	if max > 0 {
+		limit = max + 1
+	}
+	orders, err := s.findOrders(ctx, searchQuery, after, limit)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
 		}
+		return status.Errorf(codes.Internal, "Failed to search orders: %v", err)
+	}
+	if max > 0 && len(orders) > max {
+		orders = orders[:max]
+		stream.SetTrailer(metadata.Pairs(nextPageTokenKey, encodeKeyPageToken(orders[max-1].Id)))
+	}
+	for _, order := range orders {
+		// Stop sending once the client is gone.
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		// Send the matching orders in a stream
+		if err := stream.Send(order); err != nil {
+			return fmt.Errorf("error sending message to stream : %v", err)
+		}
+		log.Print("Matching Order Found : " + order.Id)
 	}
 	return nil
 }
 
 // Update multiple orders.
-// All the orders will be sent from client as a stream.
+// All the orders will be sent from client as a stream, each order is validated in the same way as AddOrder.
+// The update must follow the order lifecycle, e.g. a shipped order can't be modified.
+// An order with a non-zero version is only updated if the version is still current (compare-and-swap).
+// The outcome of each order is returned in the response, an order which can't be updated doesn't stop the other orders.
 // Client-side Streaming RPC
 func (s *orderMgtServer) UpdateOrders(stream pb.OrderManagement_UpdateOrdersServer) error {
-	ordersStr := "Updated Order IDs : "
+	res := &pb.UpdateOrdersResponse{}
 	for {
 		order, err := stream.Recv()
 		if err == io.EOF {
 			// Finished reading the order stream.
-			return stream.SendAndClose(&wrapper.StringValue{Value: "Orders processed " + ordersStr})
+			return stream.SendAndClose(res)
 		}
 
 		if err != nil {
 			return err
 		}
-		// Update order
-		orderMap[order.Id] = *order
+		// Don't apply the order once the client is gone or the deadline has passed.
+		if stream.Context().Err() != nil {
+			return contextError(stream.Context())
+		}
+		// The orders of this stream are not validated by the interceptor,
+		// so an invalid order is reported in its result instead of aborting the stream.
+		var currentVersion int64
+		updated, err := func() (*pb.Order, error) {
+			if err := validate(order); err != nil {
+				return nil, err
+			}
+			return s.updateOrder(order.Id, func(current *pb.Order) (*pb.Order, error) {
+				currentVersion = current.GetVersion()
+				if err := checkOrderVersion(current, order); err != nil {
+					return nil, err
+				}
+				if err := checkOrderUpdate(current, order); err != nil {
+					return nil, err
+				}
+				return order, nil
+			})
+		}()
+		if err != nil {
+			st, _ := status.FromError(err)
+			if st.Code() == codes.Internal {
+				return err
+			}
+			log.Printf("Order ID : %s - %s", order.Id, st.Message())
+			res.Results = append(res.Results, &pb.UpdateOrderResult{OrderId: order.Id, Status: st.Proto(), Version: currentVersion})
+			continue
+		}
 
 		log.Printf("Order ID : %s - %s", order.Id, "Updated")
-		ordersStr += order.Id + ", "
+		res.Results = append(res.Results, &pb.UpdateOrderResult{OrderId: order.Id, Status: status.New(codes.OK, "").Proto(), Version: updated.Version})
+	}
+}
+
+// Update an order partially.
+// Only the fields in the update mask are changed, the other fields keep the current values even if they are changed concurrently.
+// All the fields are replaced if the update mask is empty.
+// The updated order must be valid and follow the order lifecycle, a non-zero version is only applied if it is still current.
+// Simple RPC
+func (s *orderMgtServer) UpdateOrder(ctx context.Context, req *pb.UpdateOrderRequest) (*pb.Order, error) {
+	if req.Order == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Order is required")
+	}
+	ord, err := s.updateOrder(req.Order.Id, func(current *pb.Order) (*pb.Order, error) {
+		if current == nil {
+			return nil, status.Errorf(codes.NotFound, "Order does not exist. : %s", req.Order.Id)
+		}
+		if err := checkOrderVersion(current, req.Order); err != nil {
+			return nil, err
+		}
+		updated := proto.Clone(current).(*pb.Order)
+		if err := applyOrderMask(updated, req.Order, req.UpdateMask); err != nil {
+			return nil, err
+		}
+		if len(req.UpdateMask.GetPaths()) == 0 {
+			// The whole order is replaced, so its status is checked as in UpdateOrders.
+			updated.Status = req.Order.Status
+		}
+		if err := validate(updated); err != nil {
+			return nil, err
+		}
+		if err := checkOrderUpdate(current, updated); err != nil {
+			return nil, err
+		}
+		return updated, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("Order ID : %s - %s", ord.Id, "Updated")
+	return ord, nil
 }
 
 // Process multiple orders
 // All the order IDs will be sent from client as a stream.
 // A combined shipment will contains all the orders which will be delivered to the same destination.
-// When the max batch size is reached, all the currently created combined shipments will be sent back to the client.
+// The orders are grouped by the destination parsed and normalized under the grouping policy (exact address, city or region),
+// e.g. "mountain view,ca" and "Mountain View, CA" are the same destination.
+// Each combined shipment has a unique ID, the order count, the item count, the total price, the creation time and the normalized destination,
+// and it can be looked up by GetShipment after being sent back.
+// All the currently created combined shipments will be sent back to the client when the batch size is reached,
+// or when no order has been received within the batch wait window.
+// The client can override the batch size, the batch wait window and the grouping policy by the metadata of the stream.
+// The orders are moved to PROCESSING when they are received, and to SHIPPED when their combined shipments are sent back.
+// The orders of the batch which isn't flushed when the stream ends (e.g. the client cancels it or an error aborts it)
+// are moved back to CREATED, so they can be processed again.
+// An order ID which is empty, doesn't exist or isn't in CREATED status is rejected right away with a ProcessingError,
+// the stream goes on with the other orders.
 // Bi-directional Streaming RPC
 func (s *orderMgtServer) ProcessOrders(stream pb.OrderManagement_ProcessOrdersServer) error {
-	currentBatchSize := 1
+	batch, err := negotiateBatchConfig(stream.Context(), s.batch)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendHeader(batch.metadata()); err != nil {
+		return err
+	}
+
+	// Receive the order IDs in a separate goroutine, so the batch can be flushed by the timer while waiting for the next order.
+	type recvResult struct {
+		orderId *wrapper.StringValue
+		err     error
+	}
+	recvCh := make(chan recvResult)
+	go func() {
+		for {
+			orderId, err := stream.Recv()
+			select {
+			case recvCh <- recvResult{orderId, err}:
+			case <-stream.Context().Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	currentBatchSize := 0
 	var combinedShipmentMap = make(map[string]pb.CombinedShipment)
+	// The IDs of the orders moved to PROCESSING by this stream which haven't been shipped or rejected yet.
+	pending := make(map[string]bool)
+	defer func() {
+		for orderId := range pending {
+			if err := s.releaseProcessingOrder(orderId); err != nil {
+				log.Printf("Failed to release order %s : %v", orderId, err)
+			}
+		}
+	}()
+	var waitTimer *time.Timer
+	var waitC <-chan time.Time // Only set when there is any order waiting in the current batch.
+	defer func() {
+		if waitTimer != nil {
+			waitTimer.Stop()
+		}
+	}()
+
+	// Reject the order but keep processing the rest of the stream.
+	// Only the Internal errors abort the stream.
+	rejectOrder := func(orderId string, err error) error {
+		st, _ := status.FromError(err)
+		if st.Code() == codes.Internal {
+			return err
+		}
+		log.Printf("Order rejected : %s - %s", orderId, st.Message())
+		return stream.Send(newProcessingError(orderId, st))
+	}
+
+	// Ship and return all the combined shipments in the current batch to client.
+	flush := func() error {
+		for _, comb := range combinedShipmentMap {
+			shipment, rejected, err := s.shipOrders(&comb)
+			// The orders of a stored shipment are shipped, even if some of them couldn't be marked SHIPPED yet.
+			for _, ord := range shipment.GetOrdersList() {
+				delete(pending, ord.Id)
+			}
+			if err != nil {
+				return err
+			}
+			// The order may be cancelled while waiting in the batch, such order can't be shipped.
+			for _, r := range rejected {
+				if err := rejectOrder(r.orderId, r.err); err != nil {
+					return err
+				}
+				delete(pending, r.orderId)
+			}
+			if shipment == nil {
+				continue
+			}
+
+			log.Printf("Shipping : %v -> %v", shipment.Id, len(shipment.OrdersList))
+			if err := stream.Send(&pb.ProcessOrdersResponse{Result: &pb.ProcessOrdersResponse_Shipment{Shipment: shipment}}); err != nil {
+				return err
+			}
+		}
+		currentBatchSize = 0
+		combinedShipmentMap = make(map[string]pb.CombinedShipment)
+		waitC = nil
+		return nil
+	}
+
 	for {
-		orderId, err := stream.Recv()
+		var res recvResult
+		select {
+		case <-waitC:
+			// No order has been received within the batch wait window.
+			log.Printf("Batch wait window (%v) elapsed", batch.wait)
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		case res = <-recvCh:
+		case <-stream.Context().Done():
+			// The client is gone or the deadline has passed, the orders in the current batch are released.
+			return contextError(stream.Context())
+		}
+
+		orderId, err := res.orderId, res.err
 		log.Printf("Reading Proc order : %s", orderId)
 		if err == io.EOF {
 			// If the stream reached the end (EOF is the signal for the end of the stream)
 			// Return all the remaining combined shipments to client.
 			log.Printf("EOF : %s", orderId)
-			for _, shipment := range combinedShipmentMap {
-				if err := stream.Send(&shipment); err != nil {
-					return err
-				}
-			}
-			return nil
+			return flush()
 		}
 		if err != nil {
 			log.Println(err)
 			return err
 		}
 
-		destination := orderMap[orderId.GetValue()].Destination
-		shipment, found := combinedShipmentMap[destination]
+		ord, err := s.startProcessingOrder(orderId.GetValue())
+		if err != nil {
+			if err := rejectOrder(orderId.GetValue(), err); err != nil {
+				return err
+			}
+			continue
+		}
+		pending[ord.Id] = true
+
+		// Group the orders by the part of the parsed destination selected by the grouping policy,
+		// so the same address written in different forms ends up in the same combined shipment.
+		destination := parseDestination(ord.Destination).group(batch.grouping)
+		groupKey := strings.ToLower(destination)
+		shipment, found := combinedShipmentMap[groupKey]
 
 		if found {
 			// If the combined shipment has been found for that order by the same destination,
 			// Append the order into the combined shipment.
-			ord := orderMap[orderId.GetValue()]
-			shipment.OrdersList = append(shipment.OrdersList, &ord)
-			combinedShipmentMap[destination] = shipment
+			shipment.OrdersList = append(shipment.OrdersList, ord)
+			combinedShipmentMap[groupKey] = shipment
 		} else {
 			// If the combined shipment hasn't been found for that order by the same destination,
 			// Create a new combined shipment, append the order into it.
-			comShip := pb.CombinedShipment{Id: "cmb - " + (orderMap[orderId.GetValue()].Destination), Status: "Processed!", }
-			ord := orderMap[orderId.GetValue()]
-			comShip.OrdersList = append(shipment.OrdersList, &ord)
-			combinedShipmentMap[destination] = comShip
+			shipmentId, err := newShipmentId()
+			if err != nil {
+				return status.Errorf(codes.Internal, "Failed to generate shipment ID: %v", err)
+			}
+			comShip := pb.CombinedShipment{
+				Id:          shipmentId,
+				Status:      pb.OrderStatus_PROCESSING,
+				CreateTime:  ptypes.TimestampNow(),
+				Destination: destination,
+			}
+			comShip.OrdersList = append(shipment.OrdersList, ord)
+			combinedShipmentMap[groupKey] = comShip
 			log.Print(len(comShip.OrdersList), comShip.GetId())
 		}
+		currentBatchSize++
 
-		if currentBatchSize == maxBatchSize {
+		if currentBatchSize >= batch.size {
 			// If the current batch size reaches the max batch size,
 			// return all the combined shipments to client.
-			for _, comb := range combinedShipmentMap {
-				log.Printf("Shipping : %v -> %v" , comb.Id, len(comb.OrdersList))
-				if err := stream.Send(&comb); err != nil {
-					return err
+			if err := flush(); err != nil {
+				return err
+			}
+		} else if batch.wait > 0 {
+			// Restart the batch wait window from the latest order.
+			if waitTimer == nil {
+				waitTimer = time.NewTimer(batch.wait)
+			} else {
+				if !waitTimer.Stop() {
+					select {
+					case <-waitTimer.C:
+					default:
+					}
 				}
+				waitTimer.Reset(batch.wait)
 			}
-			currentBatchSize = 0
-			combinedShipmentMap = make(map[string]pb.CombinedShipment)
-		} else {
-			currentBatchSize++
+			waitC = waitTimer.C
+		}
+	}
+}
+
+// Cancel an order by order ID.
+// Only the orders which haven't been shipped can be cancelled, otherwise a FailedPrecondition error is returned.
+// Simple RPC
+func (s *orderMgtServer) CancelOrder(ctx context.Context, orderId *wrapper.StringValue) (*pb.Order, error) {
+	ord, err := s.updateOrder(orderId.Value, func(current *pb.Order) (*pb.Order, error) {
+		if current == nil {
+			return nil, status.Errorf(codes.NotFound, "Order does not exist. : %s", orderId.Value)
+		}
+		return current, transitionOrder(current, pb.OrderStatus_CANCELLED)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Order Cancelled. ID : %v", orderId.Value)
+	return ord, nil
+}
+
+// Get a combined shipment sent by ProcessOrders by shipment ID.
+// Simple RPC
+func (s *orderMgtServer) GetShipment(ctx context.Context, shipmentId *wrapper.StringValue) (*pb.CombinedShipment, error) {
+	shipment, err := s.shipments.Get(shipmentId.Value)
+	if err == errShipmentNotFound {
+		return nil, status.Errorf(codes.NotFound, "Shipment does not exist. : %s", shipmentId.Value)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to load shipment %s: %v", shipmentId.Value, err)
+	}
+	return shipment, nil
+}
+
+// List the combined shipments by the filters in the request (destination, status and creation time) page by page.
+// The shipments are sorted by the creation time, then the shipment ID.
+// The nextPageToken in the response is used for retrieving the next page, it will be empty on the last page.
+// The next page starts right after the last returned shipment, so the shipments stored meanwhile don't make the pages skip or repeat.
+// Simple RPC
+func (s *orderMgtServer) ListShipments(ctx context.Context, req *pb.ListShipmentsRequest) (*pb.ListShipmentsResponse, error) {
+	pageSize, err := pageSizeOf(req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	key, err := decodeKeyPageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid page token: %s", req.PageToken)
+	}
+	var afterTime *timestamp.Timestamp
+	var afterId string
+	if key != "" {
+		if afterTime, afterId, err = parseShipmentPageKey(key); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid page token: %s", req.PageToken)
+		}
+	}
+
+	shipments, err := s.findShipments(ctx, req)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
 		}
+		return nil, status.Errorf(codes.Internal, "Failed to list shipments: %v", err)
+	}
+	start := 0
+	if key != "" {
+		start = sort.Search(len(shipments), func(i int) bool {
+			return shipmentBefore(afterTime, afterId, shipments[i].CreateTime, shipments[i].Id)
+		})
+	}
+	end := start + pageSize
+	if end > len(shipments) {
+		end = len(shipments)
+	}
+
+	res := &pb.ListShipmentsResponse{Shipments: shipments[start:end]}
+	if end < len(shipments) {
+		res.NextPageToken = encodeKeyPageToken(shipmentPageKey(shipments[end-1]))
+	}
+	return res, nil
+}
+
+// Get the combined shipment which the order was shipped in.
+// Returns a NotFound error if the order hasn't been shipped.
+// Simple RPC
+func (s *orderMgtServer) GetShipmentForOrder(ctx context.Context, orderId *wrapper.StringValue) (*pb.CombinedShipment, error) {
+	shipment, err := s.shipments.GetForOrder(orderId.Value)
+	if err == errShipmentNotFound {
+		return nil, status.Errorf(codes.NotFound, "Order %s hasn't been shipped", orderId.Value)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to load shipment of order %s: %v", orderId.Value, err)
+	}
+	return shipment, nil
+}
+
+// Watch the changes of the orders.
+// The missed events after the resume token in the request are replayed first, then the new events are sent as they happen.
+// Returns an OutOfRange error if the missed events have been discarded from the event log,
+// then the client needs to get the orders again and watch from now.
+// The header is sent once the watch is started, so the client can wait for it before making the changes to watch.
+// Server-side Streaming RPC
+func (s *orderMgtServer) WatchOrders(req *pb.WatchOrdersRequest, stream pb.OrderManagement_WatchOrdersServer) error {
+	next, err := s.events.resume(req.ResumeToken)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		events, seq, wait, err := s.events.read(next)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+		next = seq
+
+		select {
+		case <-wait:
+		case <-stream.Context().Done():
+			return contextError(stream.Context())
+		}
+	}
+}
+
+// Atomically update the order in the store by fn, refresh the item index and record the change for the watchers.
+// The version of the order is increased on every change.
+// The status errors returned by fn are returned as is, the other errors are converted into Internal errors.
+func (s *orderMgtServer) updateOrder(id string, fn func(order *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	lock := &s.locks[orderLockIndex(id)]
+	lock.Lock()
+	defer lock.Unlock()
+	return s.updateLockedOrder(id, fn)
+}
+
+// Update the order in the same way as updateOrder, the caller must hold the lock of the order.
+func (s *orderMgtServer) updateLockedOrder(id string, fn func(order *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	var before *pb.Order
+	ord, err := s.store.Update(id, func(current *pb.Order) (*pb.Order, error) {
+		if current != nil {
+			before = proto.Clone(current).(*pb.Order)
+		}
+		next, err := fn(current)
+		if err != nil {
+			return nil, err
+		}
+		next.Version = before.GetVersion() + 1
+		return next, nil
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "Failed to store order %s: %v", id, err)
+	}
+	if err := s.index.refresh(id); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to index order %s: %v", id, err)
+	}
+	s.events.append(before, proto.Clone(ord).(*pb.Order))
+	return ord, nil
+}
+
+// Lock the orders against the updates, returns the function releasing the locks.
+// The locks are taken in the order of their indexes, so the callers locking overlapping orders can't deadlock.
+func (s *orderMgtServer) lockOrders(ids []string) func() {
+	seen := make(map[uint32]bool)
+	var indexes []int
+	for _, id := range ids {
+		if i := orderLockIndex(id); !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, int(i))
+		}
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		s.locks[i].Lock()
+	}
+	return func() {
+		for j := len(indexes) - 1; j >= 0; j-- {
+			s.locks[indexes[j]].Unlock()
+		}
+	}
+}
+
+// Get the index of the lock for the order ID.
+func orderLockIndex(id string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return h.Sum32() % orderLockCount
+}
+
+// Move the order received by ProcessOrders to PROCESSING.
+// Returns an InvalidArgument, NotFound or FailedPrecondition status error with the error details if the order can't be processed.
+func (s *orderMgtServer) startProcessingOrder(orderId string) (*pb.Order, error) {
+	if orderId == "" {
+		errorStatus := status.New(codes.InvalidArgument, "Order ID is empty")
+		ds, err := errorStatus.WithDetails(&epb.BadRequest{
+			FieldViolations: []*epb.BadRequest_FieldViolation{{Field: "value", Description: "Order ID must not be empty"}},
+		})
+		if err != nil {
+			return nil, errorStatus.Err()
+		}
+		return nil, ds.Err()
+	}
+
+	return s.updateOrder(orderId, func(current *pb.Order) (*pb.Order, error) {
+		if current == nil {
+			errorStatus := status.New(codes.NotFound, "Order does not exist : "+orderId)
+			ds, err := errorStatus.WithDetails(&epb.ResourceInfo{
+				ResourceType: "ecommerce.Order",
+				ResourceName: orderId,
+				Description:  "Order does not exist",
+			})
+			if err != nil {
+				return nil, errorStatus.Err()
+			}
+			return nil, ds.Err()
+		}
+		return current, transitionOrder(current, pb.OrderStatus_PROCESSING)
+	})
+}
+
+// Move the order taken by ProcessOrders but not shipped back to CREATED, so it can be processed again.
+// This is not a transition of the lifecycle, it undoes the one to PROCESSING.
+// The order which has been moved on meanwhile (e.g. cancelled) is left as is.
+func (s *orderMgtServer) releaseProcessingOrder(orderId string) error {
+	_, err := s.updateOrder(orderId, func(current *pb.Order) (*pb.Order, error) {
+		if current == nil || current.Status != pb.OrderStatus_PROCESSING {
+			return nil, errOrderNotProcessing
+		}
+		current.Status = pb.OrderStatus_CREATED
+		return current, nil
+	})
+	if err == errOrderNotProcessing {
+		return nil
+	}
+	return err
+}
+
+var errOrderNotProcessing = status.Error(codes.FailedPrecondition, "Order is not in PROCESSING")
+
+// Build the response of ProcessOrders for a rejected order.
+func newProcessingError(orderId string, st *status.Status) *pb.ProcessOrdersResponse {
+	return &pb.ProcessOrdersResponse{
+		Result: &pb.ProcessOrdersResponse_Error{
+			Error: &pb.ProcessingError{OrderId: orderId, Status: st.Proto()},
+		},
 	}
 }
 
 // Unary Interceptor (orderMgtServer-side)
-// This interceptor consists of pre-processing logic which will be executed before running the remote method,
-// post-processing logic which will be executed after running the remote method.
+// This interceptor rejects the invalid request before running the remote method.
+// The calls are logged by the call logger chained before it.
 func orderUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	// Pre-processing phase
-	// Gets info about the current RPC call by examining the args passed in
-	log.Println("======= [Server Interceptor] ", info.FullMethod)
-	log.Printf(" Pre Proc Message : %s", req)
+	if err := validate(req); err != nil {
+		return nil, err
+	}
 
 	// Invoking the handler to complete the normal execution of a unary RPC.
-	m, err := handler(ctx, req)
-
-	// Post-processing phase
-	log.Printf(" Post Proc Message : %s", m)
-	return m, err
+	return handler(ctx, req)
 }
 
-// wrappedStream wraps grpc.ServerStream and intercepts the RecvMsg and SendMsg method call.
+// wrappedStream wraps grpc.ServerStream and intercepts the RecvMsg method call.
 type wrappedStream struct {
 	grpc.ServerStream
+	validating bool // Whether to validate the received messages.
 }
 
 // Implementing the RecvMsg function of the wrapper to process messages received with stream RPC.
 func (w *wrappedStream) RecvMsg(m interface{}) error {
-	log.Printf("====== [Server Stream Interceptor Wrapper] Receive a message (Type: %T) at %s", m, time.Now().Format(time.RFC3339))
-	return w.ServerStream.RecvMsg(m)
-}
-
-// Implementing the SendMsg function of the wrapper to process messages sent with stream RPC.
-func (w *wrappedStream) SendMsg(m interface{}) error {
-	log.Printf("====== [Server Stream Interceptor Wrapper] Send a message (Type: %T) at %v", m, time.Now().Format(time.RFC3339))
-	return w.ServerStream.SendMsg(m)
+	if err := w.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if !w.validating {
+		return nil
+	}
+	// Reject the invalid message, the error is returned from stream.Recv() in the remote method.
+	return validate(m)
 }
 
 // Creating an instance of the new wrapper stream.
-func newWrappedStream(s grpc.ServerStream) grpc.ServerStream {
-	return &wrappedStream{s}
+func newWrappedStream(s grpc.ServerStream, validating bool) grpc.ServerStream {
+	return &wrappedStream{s, validating}
 }
 
 // Streaming interceptor implementation.
+// This interceptor validates the received messages, except for the methods validating them by themselves.
 func orderServerStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-	// Pre-processing phase
-	log.Println("====== [Server Stream Interceptor] ", info.FullMethod)
-
 	// Invoking the StreamHandler to complete the execution of RPC invocation
-	err := handler(srv, newWrappedStream(ss))
-	if err != nil {
-		log.Printf("RPC failed with error %v", err)
-	}
-	return err
-}
\ No newline at end of file
+	return handler(srv, newWrappedStream(ss, !selfValidatingMethods[info.FullMethod]))
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+const (
+	bufSize = 1024 * 1024
+)
+
+// Create an orderMgtServer on the order store, fail the test if it can't be created.
+func newTestOrderMgtServer(t *testing.T, store OrderStore) *orderMgtServer {
+	srv, err := newOrderMgtServer(store)
+	if err != nil {
+		t.Fatalf("newOrderMgtServer() error: %v", err)
+	}
+	return srv
+}
+
+// Start the orderMgtServer on a bufconn listener.
+// Returns the client connected to the server and the function for stopping the server.
+func startOrderMgtServer(t *testing.T, srv *orderMgtServer, opts ...grpc.ServerOption) (pb.OrderManagementClient, func()) {
+	listener := bufconn.Listen(bufSize)
+	s := grpc.NewServer(opts...)
+	pb.RegisterOrderManagementServer(s, srv)
+	go s.Serve(listener)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, url string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	return pb.NewOrderManagementClient(conn), func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func TestOrderMgtServer_AddGetUpdateOrders(t *testing.T) {
+	forEachOrderStore(t, func(t *testing.T, store OrderStore) {
+		client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store))
+		defer stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		if _, err := client.AddOrder(ctx, &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)}); err != nil {
+			t.Fatalf("AddOrder() error: %v", err)
+		}
+		if _, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "999"}); status.Code(err) != codes.NotFound {
+			t.Errorf("GetOrder() on unknown order got %v, want NotFound", err)
+		}
+
+		updateStream, err := client.UpdateOrders(ctx)
+		if err != nil {
+			t.Fatalf("UpdateOrders() error: %v", err)
+		}
+		if err := updateStream.Send(&pb.Order{Id: "101", Items: []string{"iPhone XS", "iPad Pro"}, Destination: "San Jose, CA", Price: amount.New("USD", 2100, 0)}); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+		if _, err := updateStream.CloseAndRecv(); err != nil {
+			t.Fatalf("CloseAndRecv() error: %v", err)
+		}
+
+		order, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "101"})
+		if err != nil {
+			t.Fatalf("GetOrder() error: %v", err)
+		}
+		if order.Price.GetUnits() != 2100 || len(order.Items) != 2 {
+			t.Errorf("GetOrder() = %v, want the updated order", order)
+		}
+	})
+}
+
+func TestOrderMgtServer_SearchOrders(t *testing.T) {
+	forEachOrderStore(t, func(t *testing.T, store OrderStore) {
+		if err := initSampleData(store); err != nil {
+			t.Fatalf("initSampleData() error: %v", err)
+		}
+		client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store))
+		defer stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		// Replace the items of 104, so the stale tokens must be removed from the index.
+		updateStream, err := client.UpdateOrders(ctx)
+		if err != nil {
+			t.Fatalf("UpdateOrders() error: %v", err)
+		}
+		if err := updateStream.Send(&pb.Order{Id: "104", Items: []string{"Apple TV"}, Destination: "Mountain View, CA", Price: amount.New("USD", 200, 0)}); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+		if _, err := updateStream.CloseAndRecv(); err != nil {
+			t.Fatalf("CloseAndRecv() error: %v", err)
+		}
+
+		tests := []struct {
+			name string
+			req  *pb.SearchOrdersRequest
+			want []string
+		}{
+			{"item keyword", &pb.SearchOrdersRequest{Items: "google"}, []string{"102"}},
+			{"item keywords", &pb.SearchOrdersRequest{Items: "Amazon echo"}, []string{"105", "106"}},
+			{"updated item", &pb.SearchOrdersRequest{Items: "Apple"}, []string{"103", "104", "106"}},
+			{"destination", &pb.SearchOrdersRequest{Destination: "mountain view"}, []string{"102", "104", "106"}},
+			{"price range", &pb.SearchOrdersRequest{MinPrice: amount.New("USD", 300, 0), MaxPrice: amount.New("USD", 400, 0)}, []string{"103", "106"}},
+			{"items and destination", &pb.SearchOrdersRequest{Items: "amazon", Destination: "San Jose"}, []string{"105"}},
+			{"no match", &pb.SearchOrdersRequest{Items: "Samsung"}, nil},
+		}
+		for _, tt := range tests {
+			stream, err := client.SearchOrders(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("%s: SearchOrders() error: %v", tt.name, err)
+			}
+			var got []string
+			for {
+				order, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: Recv() error: %v", tt.name, err)
+				}
+				got = append(got, order.Id)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: SearchOrders() = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestOrderMgtServer_ProcessOrdersUnknownOrder(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	stream, err := client.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("ProcessOrders() error: %v", err)
+	}
+	for _, id := range []string{"102", "999", "", "104"} {
+		if err := stream.Send(&wrapper.StringValue{Value: id}); err != nil {
+			t.Fatalf("Send(%s) error: %v", id, err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend() error: %v", err)
+	}
+
+	rejected := make(map[string]codes.Code)
+	var shipped []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error: %v", err)
+		}
+		switch result := res.Result.(type) {
+		case *pb.ProcessOrdersResponse_Shipment:
+			for _, order := range result.Shipment.OrdersList {
+				shipped = append(shipped, order.Id)
+			}
+		case *pb.ProcessOrdersResponse_Error:
+			st := status.FromProto(result.Error.Status)
+			if len(st.Details()) == 0 {
+				t.Errorf("ProcessingError for %q has no details", result.Error.OrderId)
+			}
+			rejected[result.Error.OrderId] = st.Code()
+		}
+	}
+
+	if !reflect.DeepEqual(shipped, []string{"102", "104"}) {
+		t.Errorf("shipped orders = %v, want [102 104]", shipped)
+	}
+	want := map[string]codes.Code{"999": codes.NotFound, "": codes.InvalidArgument}
+	if !reflect.DeepEqual(rejected, want) {
+		t.Errorf("rejected orders = %v, want %v", rejected, want)
+	}
+}
+
+func TestOrderMgtServer_UpdateOrdersVersion(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	for _, order := range []*pb.Order{
+		{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)},
+		{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "Mountain View, CA", Price: amount.New("USD", 550, 0)},
+	} {
+		if _, err := client.AddOrder(ctx, order); err != nil {
+			t.Fatalf("AddOrder() error: %v", err)
+		}
+	}
+	read, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "101"})
+	if err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+	if read.Version != 1 {
+		t.Fatalf("GetOrder() version = %d, want 1", read.Version)
+	}
+
+	updateOrders := func(orders ...*pb.Order) *pb.UpdateOrdersResponse {
+		stream, err := client.UpdateOrders(ctx)
+		if err != nil {
+			t.Fatalf("UpdateOrders() error: %v", err)
+		}
+		for _, order := range orders {
+			if err := stream.Send(order); err != nil {
+				t.Fatalf("Send() error: %v", err)
+			}
+		}
+		res, err := stream.CloseAndRecv()
+		if err != nil {
+			t.Fatalf("CloseAndRecv() error: %v", err)
+		}
+		return res
+	}
+
+	// Summarize each result as "<order ID> <code> v<version>".
+	summarize := func(res *pb.UpdateOrdersResponse) []string {
+		var results []string
+		for _, result := range res.Results {
+			results = append(results, fmt.Sprintf("%s %s v%d", result.OrderId, codes.Code(result.Status.GetCode()), result.Version))
+		}
+		return results
+	}
+
+	// The first update on the read version wins.
+	first := proto.Clone(read).(*pb.Order)
+	first.Price = amount.New("USD", 1200, 0)
+	if got, want := summarize(updateOrders(first)), []string{"101 OK v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UpdateOrders() results = %v, want %v", got, want)
+	}
+
+	// The second update on the same version conflicts, the other orders in the stream are still updated.
+	second := proto.Clone(read).(*pb.Order)
+	second.Description = "Space Gray"
+	res := updateOrders(second, &pb.Order{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "Mountain View, CA", Price: amount.New("USD", 500, 0)})
+	if got, want := summarize(res), []string{"101 Aborted v2", "102 OK v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UpdateOrders() results = %v, want %v", got, want)
+	}
+	details := status.FromProto(res.Results[0].Status).Details()
+	if len(details) != 1 {
+		t.Fatalf("UpdateOrders() conflict details = %v, want one PreconditionFailure", details)
+	}
+	if failure, ok := details[0].(*epb.PreconditionFailure); !ok || failure.Violations[0].Type != "ORDER_VERSION" {
+		t.Errorf("UpdateOrders() conflict details = %v, want ORDER_VERSION PreconditionFailure", details[0])
+	}
+
+	// An illegal change is reported without stopping the stream.
+	if _, err := client.CancelOrder(ctx, &wrapper.StringValue{Value: "102"}); err != nil {
+		t.Fatalf("CancelOrder() error: %v", err)
+	}
+	res = updateOrders(
+		&pb.Order{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "Mountain View, CA", Price: amount.New("USD", 450, 0), Status: pb.OrderStatus_CANCELLED},
+		&pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1100, 0)})
+	if got, want := summarize(res), []string{"102 FailedPrecondition v3", "101 OK v3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UpdateOrders() results = %v, want %v", got, want)
+	}
+
+	order, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "101"})
+	if err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+	if order.Price.GetUnits() != 1100 || order.Description != "" || order.Version != 3 {
+		t.Errorf("GetOrder() = %v, want the last update at version 3", order)
+	}
+}
+
+func TestOrderMgtServer_UpdateOrderMask(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if _, err := client.AddOrder(ctx, &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Description: "Space Gray", Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)}); err != nil {
+		t.Fatalf("AddOrder() error: %v", err)
+	}
+
+	// Two writers change different fields of the same order, neither change is lost.
+	if _, err := client.UpdateOrder(ctx, &pb.UpdateOrderRequest{
+		Order:      &pb.Order{Id: "101", Price: amount.New("USD", 1200, 0)},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"price"}},
+	}); err != nil {
+		t.Fatalf("UpdateOrder(price) error: %v", err)
+	}
+	order, err := client.UpdateOrder(ctx, &pb.UpdateOrderRequest{
+		Order:      &pb.Order{Id: "101", Destination: "Mountain View, CA"},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"destination"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateOrder(destination) error: %v", err)
+	}
+	want := &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Description: "Space Gray", Destination: "Mountain View, CA", Price: amount.New("USD", 1200, 0), Version: 3}
+	if !proto.Equal(order, want) {
+		t.Errorf("UpdateOrder() = %v, want %v", order, want)
+	}
+
+	for _, test := range []struct {
+		name string
+		req  *pb.UpdateOrderRequest
+		code codes.Code
+	}{
+		{"unknown path", &pb.UpdateOrderRequest{Order: &pb.Order{Id: "101"}, UpdateMask: &field_mask.FieldMask{Paths: []string{"id", "weight"}}}, codes.InvalidArgument},
+		{"invalid result", &pb.UpdateOrderRequest{Order: &pb.Order{Id: "101", Destination: "Mountain View"}, UpdateMask: &field_mask.FieldMask{Paths: []string{"destination"}}}, codes.InvalidArgument},
+		{"stale version", &pb.UpdateOrderRequest{Order: &pb.Order{Id: "101", Price: amount.New("USD", 1000, 0), Version: 1}, UpdateMask: &field_mask.FieldMask{Paths: []string{"price"}}}, codes.Aborted},
+		{"status path", &pb.UpdateOrderRequest{Order: &pb.Order{Id: "101", Status: pb.OrderStatus_DELIVERED}, UpdateMask: &field_mask.FieldMask{Paths: []string{"status"}}}, codes.InvalidArgument},
+		{"unknown order", &pb.UpdateOrderRequest{Order: &pb.Order{Id: "999", Price: amount.New("USD", 1000, 0)}, UpdateMask: &field_mask.FieldMask{Paths: []string{"price"}}}, codes.NotFound},
+	} {
+		if _, err := client.UpdateOrder(ctx, test.req); status.Code(err) != test.code {
+			t.Errorf("UpdateOrder() with %s got %v, want %v", test.name, err, test.code)
+		}
+	}
+
+	order, err = client.GetOrder(ctx, &wrapper.StringValue{Value: "101"})
+	if err != nil {
+		t.Fatalf("GetOrder() error: %v", err)
+	}
+	if !proto.Equal(order, want) {
+		t.Errorf("GetOrder() = %v after the failed updates, want %v", order, want)
+	}
+}
+
+func TestOrderMgtServer_AddOrdersBatchGetOrders(t *testing.T) {
+	// The interceptors are registered to check the invalid order doesn't abort the stream.
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()),
+		grpc.UnaryInterceptor(orderUnaryServerInterceptor),
+		grpc.StreamInterceptor(orderServerStreamInterceptor))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	stream, err := client.AddOrders(ctx)
+	if err != nil {
+		t.Fatalf("AddOrders() error: %v", err)
+	}
+	for _, order := range []*pb.Order{
+		{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)},
+		{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "Mountain View", Price: amount.New("USD", 300, 0)},
+		{Id: "103", Items: []string{"Apple Watch S4"}, Destination: "San Jose, CA", Price: amount.New("USD", 400, 0)},
+	} {
+		if err := stream.Send(order); err != nil {
+			t.Fatalf("Send() error: %v", err)
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv() error: %v", err)
+	}
+	var got []string
+	for _, result := range res.Results {
+		got = append(got, fmt.Sprintf("%s:%s:%d", result.OrderId, codes.Code(result.Status.GetCode()), result.Version))
+	}
+	if want := []string{"101:OK:1", "102:InvalidArgument:0", "103:OK:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AddOrders() results = %v, want %v", got, want)
+	}
+	if fields := violatedFields(t, status.FromProto(res.Results[1].Status).Err()); !reflect.DeepEqual(fields, []string{"destination"}) {
+		t.Errorf("AddOrders() violated fields of 102 = %v, want [destination]", fields)
+	}
+
+	batch, err := client.BatchGetOrders(ctx, &pb.BatchGetOrdersRequest{OrderIds: []string{"103", "102", "101"}})
+	if err != nil {
+		t.Fatalf("BatchGetOrders() error: %v", err)
+	}
+	var ids []string
+	for _, order := range batch.Orders {
+		ids = append(ids, order.Id)
+	}
+	if !reflect.DeepEqual(ids, []string{"103", "101"}) || !reflect.DeepEqual(batch.MissingIds, []string{"102"}) {
+		t.Errorf("BatchGetOrders() = %v found, %v missing, want [103 101] found, [102] missing", ids, batch.MissingIds)
+	}
+
+	_, err = client.BatchGetOrders(ctx, &pb.BatchGetOrdersRequest{OrderIds: make([]string, maxBatchGetOrders+1)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BatchGetOrders() with too many IDs got %v, want InvalidArgument", err)
+	}
+}
+
+func TestOrderMgtServer_SearchOrdersPaging(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// Collect the order IDs page by page, until there is no next page token.
+	var pages [][]string
+	req := &pb.SearchOrdersRequest{Destination: ",", MaxResults: 2}
+	for {
+		stream, err := client.SearchOrders(ctx, req)
+		if err != nil {
+			t.Fatalf("SearchOrders() error: %v", err)
+		}
+		var page []string
+		for {
+			order, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Recv() error: %v", err)
+			}
+			page = append(page, order.Id)
+		}
+		pages = append(pages, page)
+		tokens := stream.Trailer().Get(nextPageTokenKey)
+		if len(tokens) == 0 {
+			break
+		}
+		req.PageToken = tokens[0]
+	}
+	want := [][]string{{"102", "103"}, {"104", "105"}, {"106"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("SearchOrders() pages = %v, want %v", pages, want)
+	}
+
+	for _, req := range []*pb.SearchOrdersRequest{{MaxResults: -1}, {PageToken: "!"}} {
+		stream, err := client.SearchOrders(ctx, req)
+		if err != nil {
+			t.Fatalf("SearchOrders() error: %v", err)
+		}
+		if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("SearchOrders(%v) got %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestOrderMgtServer_FindOrdersCancelled(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	srv := newTestOrderMgtServer(t, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, req := range []*pb.SearchOrdersRequest{{}, {Items: "Google"}} {
+		if _, err := srv.findOrders(ctx, req, "", 0); status.Code(err) != codes.Canceled {
+			t.Errorf("findOrders(%v) with cancelled context got %v, want Canceled", req, err)
+		}
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := srv.findOrders(ctx, &pb.SearchOrdersRequest{}, "", 0); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("findOrders() after deadline got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestOrderMgtServer_FindOrdersLimit(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	srv := newTestOrderMgtServer(t, store)
+
+	// Both the scan and the index lookup only return the first orders by order ID.
+	for _, test := range []struct {
+		req  *pb.SearchOrdersRequest
+		want string
+	}{
+		{&pb.SearchOrdersRequest{}, "[103 104]"},
+		{&pb.SearchOrdersRequest{Destination: "Mountain View"}, "[104 106]"},
+		{&pb.SearchOrdersRequest{Items: "Amazon"}, "[105 106]"},
+		{&pb.SearchOrdersRequest{Items: "Apple"}, "[103 106]"},
+	} {
+		orders, err := srv.findOrders(context.Background(), test.req, "102", 2)
+		if err != nil {
+			t.Fatalf("findOrders(%v) error: %v", test.req, err)
+		}
+		var ids []string
+		for _, order := range orders {
+			ids = append(ids, order.Id)
+		}
+		if fmt.Sprint(ids) != test.want {
+			t.Errorf("findOrders(%v) with limit 2 = %v, want %s", test.req, ids, test.want)
+		}
+	}
+}
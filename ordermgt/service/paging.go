@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/base64"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// Get the effective page size of the request, returns an InvalidArgument error if it is negative.
+// 0 means the default page size, and the page size is capped at the max page size.
+func pageSizeOf(pageSize int32) (int, error) {
+	switch {
+	case pageSize < 0:
+		return 0, status.Errorf(codes.InvalidArgument, "Page size must not be negative: %d", pageSize)
+	case pageSize == 0:
+		return defaultPageSize, nil
+	case pageSize > maxPageSize:
+		return maxPageSize, nil
+	}
+	return int(pageSize), nil
+}
+
+// Encode the last returned key (e.g. the order ID) into an opaque page token.
+// The next page starts right after the key, so the inserted or removed entries don't shift the pages.
+func encodeKeyPageToken(key string) string {
+	return base64.URLEncoding.EncodeToString([]byte(key))
+}
+
+// Decode the last returned key from the page token, empty token means the first page.
+func decodeKeyPageToken(token string) (string, error) {
+	b, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
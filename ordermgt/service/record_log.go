@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// The header of each record in the log: 4 bytes for the length and 4 bytes for the CRC-32 checksum of the data.
+const recordHeaderSize = 8
+
+// The max size of the data of a record, a larger length in the header means the log is corrupted.
+const maxRecordSize = 16 << 20
+
+// recordLog is an append-only log file of checksummed records, shared by the file stores.
+// The appends are not synchronized, the store using the log must serialize them.
+//
+// Record format: | length (4 bytes) | CRC-32 of data (4 bytes) | data |
+type recordLog struct {
+	file *os.File
+}
+
+// Open the log at path (create it if it doesn't exist) and call load on the data of each record in it.
+// A torn record at the end of the log (caused by a crash in the middle of a write) will be truncated,
+// but a broken record followed by more records fails the open, so the valid records after it are never dropped.
+func openRecordLog(path string, load func(data []byte, offset int64) error) (*recordLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l := &recordLog{file: file}
+	validSize, err := l.replay(load)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Truncate(validSize); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Read all the records in the log.
+// Returns the size of the log which only contains the complete records.
+func (l *recordLog) replay(load func(data []byte, offset int64) error) (int64, error) {
+	info, err := l.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	r := bufio.NewReader(l.file)
+	var offset int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			// io.EOF means the end of the log, io.ErrUnexpectedEOF means a torn header at the end of the log.
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return offset, nil
+			}
+			return 0, err
+		}
+		length := int64(binary.BigEndian.Uint32(header[0:4]))
+		if length > maxRecordSize {
+			return 0, fmt.Errorf("corrupted record at offset %d of %s: length %d exceeds the max record size %d", offset, l.file.Name(), length, maxRecordSize)
+		}
+		end := offset + recordHeaderSize + length
+		if end > size {
+			// The data of the last record is torn.
+			return offset, nil
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return 0, err
+		}
+		if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+			if end == size {
+				// The last record is torn.
+				return offset, nil
+			}
+			return 0, fmt.Errorf("corrupted record at offset %d of %s: checksum mismatch", offset, l.file.Name())
+		}
+		if err := load(data, offset); err != nil {
+			return 0, err
+		}
+		offset = end
+	}
+}
+
+// Append a record of the data and flush it to the disk.
+func (l *recordLog) append(data []byte) error {
+	if len(data) > maxRecordSize {
+		return fmt.Errorf("record of %d bytes exceeds the max record size %d", len(data), maxRecordSize)
+	}
+	record := make([]byte, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[recordHeaderSize:], data)
+
+	if _, err := l.file.Write(record); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+func (l *recordLog) Close() error {
+	return l.file.Close()
+}
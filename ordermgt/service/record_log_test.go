@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Write the records into a new log at path, then apply the damage to the log file.
+func writeRecordLog(t *testing.T, path string, records []string, damage func(data []byte) []byte) {
+	t.Helper()
+	l, err := openRecordLog(path, func(data []byte, offset int64) error { return nil })
+	if err != nil {
+		t.Fatalf("openRecordLog() error: %v", err)
+	}
+	for _, record := range records {
+		if err := l.append([]byte(record)); err != nil {
+			t.Fatalf("append() error: %v", err)
+		}
+	}
+	l.Close()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, damage(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Open the log and get its records.
+func readRecordLog(path string) ([]string, error) {
+	var records []string
+	l, err := openRecordLog(path, func(data []byte, offset int64) error {
+		records = append(records, string(data))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.Close()
+	return records, nil
+}
+
+func TestRecordLog_Damage(t *testing.T) {
+	records := []string{"first", "second", "third"}
+	// The offset of the data of the second record.
+	second := recordHeaderSize + len("first") + recordHeaderSize
+	for _, c := range []struct {
+		name   string
+		damage func(data []byte) []byte
+		want   string // The records left after the open, or the error.
+		err    bool
+	}{
+		{"torn header", func(data []byte) []byte { return append(data, 0, 0, 0) }, "first second third", false},
+		{"torn data", func(data []byte) []byte { return data[:len(data)-2] }, "first second", false},
+		{"length past the end", func(data []byte) []byte { return append(data, 0, 0, 0, 100, 1, 2, 3, 4, 'x') }, "first second third", false},
+		{"checksum of the last record", func(data []byte) []byte { data[len(data)-1] ^= 0xff; return data }, "first second", false},
+		{"checksum in the middle", func(data []byte) []byte { data[second] ^= 0xff; return data }, "checksum mismatch", true},
+		{"length over the max in the middle", func(data []byte) []byte {
+			binary.BigEndian.PutUint32(data[second-recordHeaderSize:], maxRecordSize+1)
+			return data
+		}, "exceeds the max record size", true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+			path := filepath.Join(dir, "records.log")
+			writeRecordLog(t, path, records, c.damage)
+			size := fileSize(t, path)
+
+			got, err := readRecordLog(path)
+			if c.err {
+				if err == nil || !strings.Contains(err.Error(), c.want) {
+					t.Fatalf("openRecordLog() error = %v, want %s", err, c.want)
+				}
+				// The damaged log is left as is for the recovery.
+				if fileSize(t, path) != size {
+					t.Errorf("openRecordLog() changed the size of the damaged log")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openRecordLog() error: %v", err)
+			}
+			if strings.Join(got, " ") != c.want {
+				t.Errorf("records = %v, want %s", got, c.want)
+			}
+			// The torn tail is truncated, so the new records are appended after the valid ones.
+			l, err := openRecordLog(path, func(data []byte, offset int64) error { return nil })
+			if err != nil {
+				t.Fatalf("openRecordLog() error: %v", err)
+			}
+			l.append([]byte("fourth"))
+			l.Close()
+			if got, err := readRecordLog(path); err != nil || strings.Join(got, " ") != c.want+" fourth" {
+				t.Errorf("records after append = %v, %v, want %s fourth", got, err, c.want)
+			}
+		})
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"grpc-up-and-running/common/calllog"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/metrics"
+	"grpc-up-and-running/common/recovery"
+	"grpc-up-and-running/common/recovery/recoverytest"
+	"grpc-up-and-running/common/tracing"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Unary interceptor panicking on the order ID "panic", as a remote method with a bug would.
+func panickingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if id, ok := req.(*wrapper.StringValue); ok && id.Value == "panic" {
+		panic("order ID panic")
+	}
+	return handler(ctx, req)
+}
+
+// Stream interceptor panicking on watchOrders.
+func panickingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if info.FullMethod == "/ecommerce.OrderManagement/watchOrders" {
+		var nilMap map[string]int
+		nilMap["watch"]++
+	}
+	return handler(srv, ss)
+}
+
+func TestPanicRecovery_Interceptors(t *testing.T) {
+	var out recoverytest.SyncBuffer
+	serverMetrics := metrics.NewServerMetrics()
+	panicRecovery := recovery.New(log.New(&out, "", 0), serverMetrics)
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store),
+		grpc.UnaryInterceptor(interceptor.ChainUnaryServer(panicRecovery.UnaryInterceptor, panickingUnaryInterceptor)),
+		grpc.StreamInterceptor(interceptor.ChainStreamServer(panicRecovery.StreamInterceptor, panickingStreamInterceptor)))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// The panic is returned as Internal, and logged with the stack trace under the same correlation ID.
+	_, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "panic"})
+	id := recoverytest.CorrelationId(t, err)
+	logs := out.String()
+	if !strings.Contains(logs, "panic recovered in /ecommerce.OrderManagement/getOrder, correlation ID "+id+": order ID panic") || !strings.Contains(logs, "runtime/debug.Stack") {
+		t.Errorf("logs = %q, want the panic with the correlation ID %s and the stack trace", logs, id)
+	}
+
+	watchStream, err := client.WatchOrders(ctx, &pb.WatchOrdersRequest{})
+	if err != nil {
+		t.Fatalf("WatchOrders() error: %v", err)
+	}
+	_, err = watchStream.Recv()
+	if watchId := recoverytest.CorrelationId(t, err); watchId == id {
+		t.Errorf("the correlation IDs of the 2 panics are both %s", id)
+	}
+
+	// The server keeps serving the other calls.
+	if order, err := client.GetOrder(ctx, &wrapper.StringValue{Value: "102"}); err != nil || order.Id != "102" {
+		t.Errorf("GetOrder() after the panics = %v, %v, want order 102", order, err)
+	}
+	if shipments := processOrders(t, ctx, client, "102", "103"); len(shipments) == 0 {
+		t.Errorf("ProcessOrders() after the panics got no shipments")
+	}
+
+	var body strings.Builder
+	serverMetrics.WriteText(&body)
+	for _, want := range []string{
+		`grpc_server_panics_recovered_total{grpc_type="unary",grpc_service="ecommerce.OrderManagement",grpc_method="getOrder"} 1`,
+		`grpc_server_panics_recovered_total{grpc_type="server_stream",grpc_service="ecommerce.OrderManagement",grpc_method="watchOrders"} 1`,
+	} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("metrics don't contain %q:\n%s", want, body.String())
+		}
+	}
+}
+
+// The interceptors inside the recovery interceptor still record the calls which panic.
+func TestPanicRecovery_InnerInterceptors(t *testing.T) {
+	var out, logs recoverytest.SyncBuffer
+	serverMetrics := metrics.NewServerMetrics()
+	panicRecovery := recovery.New(log.New(&out, "", 0), serverMetrics)
+	callLog := newTestCallLogger(t, &logs, calllog.Config{Format: "logfmt", Level: calllog.LevelInfo})
+	exporter := tracing.NewMemorySpanExporter()
+	tracer := newTracer(exporter)
+	unary, stream, err := newServerPipeline([]serverInterceptor{
+		{name: "recovery", unary: panicRecovery.UnaryInterceptor, stream: panicRecovery.StreamInterceptor},
+		{name: "metrics", unary: serverMetrics.UnaryInterceptor, stream: serverMetrics.StreamInterceptor},
+		{name: "logging", unary: callLog.unaryServerInterceptor, stream: callLog.streamServerInterceptor},
+		{name: "tracing", unary: tracer.unaryServerInterceptor, stream: tracer.streamServerInterceptor},
+		{name: "panics", unary: panickingUnaryInterceptor, stream: panickingStreamInterceptor},
+	}, parseInterceptorNames("recovery,metrics,logging,tracing,panics"))
+	if err != nil {
+		t.Fatalf("newServerPipeline() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()),
+		grpc.UnaryInterceptor(interceptor.ChainUnaryServer(unary...)),
+		grpc.StreamInterceptor(interceptor.ChainStreamServer(stream...)))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err = client.GetOrder(ctx, &wrapper.StringValue{Value: "panic"})
+	recoverytest.CorrelationId(t, err)
+	watchStream, err := client.WatchOrders(ctx, &pb.WatchOrdersRequest{})
+	if err != nil {
+		t.Fatalf("WatchOrders() error: %v", err)
+	}
+	_, err = watchStream.Recv()
+	recoverytest.CorrelationId(t, err)
+
+	// The stream isn't left in flight, and both calls are counted as Internal.
+	var body strings.Builder
+	serverMetrics.WriteText(&body)
+	for _, want := range []string{
+		`grpc_server_handled_total{grpc_type="unary",grpc_service="ecommerce.OrderManagement",grpc_method="getOrder",grpc_code="Internal"} 1`,
+		`grpc_server_handled_total{grpc_type="server_stream",grpc_service="ecommerce.OrderManagement",grpc_method="watchOrders",grpc_code="Internal"} 1`,
+		`grpc_server_streams_in_flight{grpc_type="server_stream",grpc_service="ecommerce.OrderManagement",grpc_method="watchOrders"} 0`,
+	} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("metrics don't contain %q:\n%s", want, body.String())
+		}
+	}
+
+	// The calls are logged and traced with the Internal code.
+	for _, want := range []string{
+		`method=/ecommerce.OrderManagement/getOrder code=Internal`,
+		`method=/ecommerce.OrderManagement/watchOrders code=Internal`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("call logs don't contain %q:\n%s", want, logs.String())
+		}
+	}
+	for _, name := range []string{"ecommerce.OrderManagement/getOrder", "ecommerce.OrderManagement/watchOrders"} {
+		if s := findSpan(exporter.Spans(), name); s == nil || s.StatusCode != codes.Internal {
+			t.Errorf("span %s = %+v, want the span with the Internal status", name, s)
+		}
+	}
+}
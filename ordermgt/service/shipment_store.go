@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	pb "ordergmt/service/ecommerce"
+)
+
+var errShipmentNotFound = errors.New("shipment not found")
+
+// ShipmentStore is the storage of the combined shipments sent by ProcessOrders.
+// The store links each shipped order to its shipment.
+// The shipments passed in and returned are copies, so the caller can modify them without affecting the store.
+type ShipmentStore interface {
+	// Get a shipment by shipment ID, returns errShipmentNotFound if the shipment does not exist.
+	Get(id string) (*pb.CombinedShipment, error)
+	// GetForOrder gets the shipment containing the order, returns errShipmentNotFound if the order hasn't been shipped.
+	GetForOrder(orderId string) (*pb.CombinedShipment, error)
+	// Put a shipment, the existing shipment with the same shipment ID will be replaced.
+	Put(shipment *pb.CombinedShipment) error
+	// Range calls f on each shipment in the store until f returns false.
+	Range(f func(shipment *pb.CombinedShipment) bool) error
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// memoryShipmentStore keeps the shipments in memory, all the shipments will be lost when the process exits.
+type memoryShipmentStore struct {
+	mu        sync.RWMutex
+	shipments map[string]*pb.CombinedShipment
+	byOrder   map[string]string // The shipment ID by the order ID.
+}
+
+// Create an empty in-memory shipment store.
+func newMemoryShipmentStore() *memoryShipmentStore {
+	return &memoryShipmentStore{
+		shipments: make(map[string]*pb.CombinedShipment),
+		byOrder:   make(map[string]string),
+	}
+}
+
+func (m *memoryShipmentStore) Get(id string) (*pb.CombinedShipment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	shipment, exists := m.shipments[id]
+	if !exists {
+		return nil, errShipmentNotFound
+	}
+	return proto.Clone(shipment).(*pb.CombinedShipment), nil
+}
+
+func (m *memoryShipmentStore) GetForOrder(orderId string) (*pb.CombinedShipment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	shipment, exists := m.shipments[m.byOrder[orderId]]
+	if !exists {
+		return nil, errShipmentNotFound
+	}
+	return proto.Clone(shipment).(*pb.CombinedShipment), nil
+}
+
+func (m *memoryShipmentStore) Put(shipment *pb.CombinedShipment) error {
+	shipment = proto.Clone(shipment).(*pb.CombinedShipment)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, exists := m.shipments[shipment.Id]; exists {
+		for _, order := range old.OrdersList {
+			delete(m.byOrder, order.Id)
+		}
+	}
+	m.shipments[shipment.Id] = shipment
+	for _, order := range shipment.OrdersList {
+		m.byOrder[order.Id] = shipment.Id
+	}
+	return nil
+}
+
+// Range takes a snapshot of the shipments and calls f without holding the lock,
+// so f is free to call the other methods of the store.
+func (m *memoryShipmentStore) Range(f func(shipment *pb.CombinedShipment) bool) error {
+	m.mu.RLock()
+	shipments := make([]*pb.CombinedShipment, 0, len(m.shipments))
+	for _, shipment := range m.shipments {
+		shipments = append(shipments, proto.Clone(shipment).(*pb.CombinedShipment))
+	}
+	m.mu.RUnlock()
+
+	for _, shipment := range shipments {
+		if !f(shipment) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *memoryShipmentStore) Close() error {
+	return nil
+}
+
+// fileShipmentStore persists the shipments into an append-only log file, in the same format as fileOrderStore.
+// All the shipments are also kept in memory for serving the reads.
+type fileShipmentStore struct {
+	*memoryShipmentStore
+	mu  sync.Mutex // Serializes the appends to the log file.
+	log *recordLog // The records of the marshalled shipments.
+}
+
+// Open the shipment log at path (create it if it doesn't exist) and load all the shipments in it.
+func openFileShipmentStore(path string) (*fileShipmentStore, error) {
+	s := &fileShipmentStore{memoryShipmentStore: newMemoryShipmentStore()}
+	log, err := openRecordLog(path, func(data []byte, offset int64) error {
+		shipment := &pb.CombinedShipment{}
+		if err := proto.Unmarshal(data, shipment); err != nil {
+			return fmt.Errorf("corrupted shipment record at offset %d: %v", offset, err)
+		}
+		return s.memoryShipmentStore.Put(shipment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.log = log
+	return s, nil
+}
+
+func (s *fileShipmentStore) Put(shipment *pb.CombinedShipment) error {
+	data, err := proto.Marshal(shipment)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.log.append(data); err != nil {
+		return err
+	}
+	return s.memoryShipmentStore.Put(shipment)
+}
+
+func (s *fileShipmentStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
+
+// Create the shipment store by the store type.
+func newShipmentStore(storeType string, path string) (ShipmentStore, error) {
+	switch storeType {
+	case "memory":
+		return newMemoryShipmentStore(), nil
+	case "file":
+		return openFileShipmentStore(path)
+	default:
+		return nil, fmt.Errorf("unknown shipment store type: %s", storeType)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Run the test function against every type of the shipment store.
+func forEachShipmentStore(t *testing.T, test func(t *testing.T, store ShipmentStore)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newMemoryShipmentStore())
+	})
+	t.Run("file", func(t *testing.T) {
+		dir, cleanup := tempDir(t)
+		defer cleanup()
+		store, err := openFileShipmentStore(filepath.Join(dir, "shipments.log"))
+		if err != nil {
+			t.Fatalf("openFileShipmentStore() error: %v", err)
+		}
+		defer store.Close()
+		test(t, store)
+	})
+}
+
+func TestShipmentStore_PutGetRange(t *testing.T) {
+	forEachShipmentStore(t, func(t *testing.T, store ShipmentStore) {
+		if _, err := store.Get("cmb-1"); err != errShipmentNotFound {
+			t.Fatalf("Get() on empty store error = %v, want %v", err, errShipmentNotFound)
+		}
+
+		shipment := &pb.CombinedShipment{Id: "cmb-1", Destination: "San Jose, CA", OrdersList: []*pb.Order{{Id: "101"}, {Id: "102"}}}
+		if err := store.Put(shipment); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+		// Modifying the shipment after Put must not affect the stored one.
+		shipment.Destination = "Mountain View, CA"
+		if err := store.Put(&pb.CombinedShipment{Id: "cmb-2", OrdersList: []*pb.Order{{Id: "103"}}}); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+
+		got, err := store.Get("cmb-1")
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		if got.Destination != "San Jose, CA" {
+			t.Errorf("Get() destination = %q, want %q", got.Destination, "San Jose, CA")
+		}
+		got, err = store.GetForOrder("102")
+		if err != nil {
+			t.Fatalf("GetForOrder() error: %v", err)
+		}
+		if got.Id != "cmb-1" {
+			t.Errorf("GetForOrder(102) = %s, want cmb-1", got.Id)
+		}
+		if _, err := store.GetForOrder("104"); err != errShipmentNotFound {
+			t.Errorf("GetForOrder() on unshipped order error = %v, want %v", err, errShipmentNotFound)
+		}
+
+		count := 0
+		if err := store.Range(func(shipment *pb.CombinedShipment) bool {
+			count++
+			return true
+		}); err != nil {
+			t.Fatalf("Range() error: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Range() visited %d shipments, want 2", count)
+		}
+	})
+}
+
+func TestFileShipmentStore_Reopen(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "shipments.log")
+	store, err := openFileShipmentStore(path)
+	if err != nil {
+		t.Fatalf("openFileShipmentStore() error: %v", err)
+	}
+	want := &pb.CombinedShipment{Id: "cmb-1", Status: pb.OrderStatus_SHIPPED, OrdersList: []*pb.Order{{Id: "101"}}, OrderCount: 1}
+	for _, shipment := range []*pb.CombinedShipment{{Id: "cmb-1", Status: pb.OrderStatus_PROCESSING}, want} {
+		if err := store.Put(shipment); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	store.Close()
+
+	store, err = openFileShipmentStore(path)
+	if err != nil {
+		t.Fatalf("openFileShipmentStore() error: %v", err)
+	}
+	defer store.Close()
+	got, err := store.GetForOrder("101")
+	if err != nil {
+		t.Fatalf("GetForOrder() error: %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("GetForOrder() after reopen = %v, want %v", got, want)
+	}
+}
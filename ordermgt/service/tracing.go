@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/tracing"
+)
+
+// tracer adds the service interceptors to the tracer of the common module.
+type tracer struct {
+	*tracing.Tracer
+}
+
+// Create a tracer exporting the spans to exporter, or discarding them if exporter is nil.
+func newTracer(exporter tracing.SpanExporter) *tracer {
+	return &tracer{tracing.NewTracer(exporter)}
+}
+
+// Continue the trace sent by the client in the traceparent metadata.
+// The invalid traceparent is ignored and a new trace is started, as the W3C trace context recommends.
+func serverTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	values := md.Get(tracing.TraceparentKey)
+	if len(values) == 0 {
+		return ctx
+	}
+	tc, err := tracing.ParseTraceparent(values[0])
+	if err != nil {
+		return ctx
+	}
+	return tracing.NewContext(ctx, tc)
+}
+
+// Unary interceptor creating a server span per call, the span is in the context of the remote method.
+func (t *tracer) unaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	name, attributes := tracing.RpcSpan(interceptor.FullMethod(ctx, info.FullMethod))
+	ctx, s := t.StartSpan(serverTraceContext(ctx), name, tracing.SpanKindServer, attributes...)
+	defer finishServerSpan(s, &err)
+	return handler(ctx, req)
+}
+
+// Stream interceptor creating a server span per call, and a child span per message received and sent.
+func (t *tracer) streamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	name, attributes := tracing.RpcSpan(interceptor.FullMethod(ss.Context(), info.FullMethod))
+	ctx, s := t.StartSpan(serverTraceContext(ss.Context()), name, tracing.SpanKindServer, attributes...)
+	defer finishServerSpan(s, &err)
+	return handler(srv, &tracingServerStream{ServerStream: ss, ctx: ctx, tracer: t, name: name})
+}
+
+// End the server span of the call with its error, it is deferred by the interceptors so the span also ends if the handler panics.
+// The panic goes on to the recovery interceptor.
+func finishServerSpan(s *tracing.Span, err *error) {
+	if r := recover(); r != nil {
+		tracing.FinishRpcSpan(s, interceptor.PanicError(r))
+		panic(r)
+	}
+	tracing.FinishRpcSpan(s, *err)
+}
+
+// tracingServerStream carries the span of the call in its context, and records the messages as the child spans.
+type tracingServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	tracer   *tracer
+	name     string
+	received int64 // Updated atomically, the messages may be received in another goroutine.
+	sent     int64
+}
+
+func (s *tracingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tracingServerStream) RecvMsg(m interface{}) error {
+	start := s.tracer.Now()
+	err := s.ServerStream.RecvMsg(m)
+	if err == io.EOF {
+		return err
+	}
+	s.tracer.MessageSpan(s.ctx, s.name+"/recv", "RECEIVED", atomic.AddInt64(&s.received, 1), start, err)
+	return err
+}
+
+func (s *tracingServerStream) SendMsg(m interface{}) error {
+	start := s.tracer.Now()
+	err := s.ServerStream.SendMsg(m)
+	s.tracer.MessageSpan(s.ctx, s.name+"/send", "SENT", atomic.AddInt64(&s.sent, 1), start, err)
+	return err
+}
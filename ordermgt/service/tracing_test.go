@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"grpc-up-and-running/common/tracing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// Find the exported span by name.
+func findSpan(spans []*tracing.Span, name string) *tracing.Span {
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestTracer_ServerInterceptors(t *testing.T) {
+	exporter := tracing.NewMemorySpanExporter()
+	tracer := newTracer(exporter)
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store),
+		grpc.UnaryInterceptor(tracer.unaryServerInterceptor),
+		grpc.StreamInterceptor(tracer.streamServerInterceptor))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	parent := mustParseTraceparent(t, testTraceparent)
+
+	// The server span of the call continues the trace of the client.
+	tracedCtx := metadata.AppendToOutgoingContext(ctx, tracing.TraceparentKey, testTraceparent)
+	client.GetOrder(tracedCtx, &wrapper.StringValue{Value: "999"})
+	getOrder := findSpan(exporter.Spans(), "ecommerce.OrderManagement/getOrder")
+	if getOrder == nil {
+		t.Fatalf("no span of getOrder in %v", exporter.Spans())
+	}
+	if getOrder.Context.TraceId != parent.TraceId || getOrder.ParentSpanId != parent.SpanId || getOrder.Kind != tracing.SpanKindServer {
+		t.Errorf("getOrder span = %+v, want the server span child of %s", getOrder, testTraceparent)
+	}
+	if getOrder.StatusCode != codes.NotFound {
+		t.Errorf("getOrder span status = %v, want NotFound", getOrder.StatusCode)
+	}
+
+	// The stream messages are the child spans of the stream.
+	processOrders(t, tracedCtx, client, "102", "103")
+	spans := exporter.Spans()
+	stream := findSpan(spans, "ecommerce.OrderManagement/processOrders")
+	if stream == nil {
+		t.Fatalf("no span of processOrders in %v", spans)
+	}
+	var received, sent int
+	for _, s := range spans {
+		if s.ParentSpanId != stream.Context.SpanId {
+			continue
+		}
+		switch s.Name {
+		case "ecommerce.OrderManagement/processOrders/recv":
+			received++
+		case "ecommerce.OrderManagement/processOrders/send":
+			sent++
+		}
+		if s.Context.TraceId != parent.TraceId {
+			t.Errorf("message span %+v is not in the trace", s)
+		}
+	}
+	if received != 2 || sent == 0 {
+		t.Errorf("got %d received and %d sent message spans, want 2 received and some sent", received, sent)
+	}
+
+	// The call without traceparent starts a new trace, and the unsampled trace isn't exported.
+	count := len(exporter.Spans())
+	client.GetOrder(ctx, &wrapper.StringValue{Value: "102"})
+	if spans := exporter.Spans(); len(spans) != count+1 || spans[count].ParentSpanId != [8]byte{} || spans[count].Context.TraceId == parent.TraceId {
+		t.Errorf("span of the call without traceparent = %+v, want a root span of a new trace", spans[count:])
+	}
+	client.GetOrder(metadata.AppendToOutgoingContext(ctx, tracing.TraceparentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"), &wrapper.StringValue{Value: "102"})
+	if got := len(exporter.Spans()); got != count+1 {
+		t.Errorf("got %d spans after the unsampled call, want %d", got, count+1)
+	}
+}
+
+func mustParseTraceparent(t *testing.T, s string) tracing.TraceContext {
+	tc, err := tracing.ParseTraceparent(s)
+	if err != nil {
+		t.Fatalf("tracing.ParseTraceparent() error: %v", err)
+	}
+	return tc
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/golang/protobuf/proto"
+	money "google.golang.org/genproto/googleapis/type/money"
+	"grpc-up-and-running/common/validation"
+	pb "ordergmt/service/ecommerce"
+)
+
+var (
+	orderIdPattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+	destinationPattern = regexp.MustCompile(`^\s*[^,\s][^,]*(,\s*[^,\s][^,]*)+$`)
+)
+
+// The validation rules of the request messages, by the full name of the message.
+// All the rules of a message are checked, every violated field is reported in one BadRequest.
+var validationRules = validation.Rules{
+	proto.MessageName(&pb.Order{}): {
+		validation.StringField("id", func(m proto.Message) string { return m.(*pb.Order).Id },
+			validation.Required(),
+			validation.Pattern(orderIdPattern, "must start with a letter or a digit, and contain at most 64 letters, digits, '_' or '-'")),
+		validation.ListField("items", func(m proto.Message) []string { return m.(*pb.Order).Items }, 1,
+			validation.Required(), validation.MaxLength(200)),
+		validation.MoneyField("price", func(m proto.Message) *money.Money { return m.(*pb.Order).Price },
+			validation.Positive()),
+		validation.StringField("destination", func(m proto.Message) string { return m.(*pb.Order).Destination },
+			validation.Required(),
+			validation.Pattern(destinationPattern, `must be in the format of "[<street>, ]<city>, <region>[ <postal code>]"`)),
+		validation.StringField("description", func(m proto.Message) string { return m.(*pb.Order).Description },
+			validation.MaxLength(500)),
+	},
+}
+
+// Validate the message by the rules of its type, the message without rules is always valid.
+// Returns an InvalidArgument error with the BadRequest details listing every violated field.
+func validate(m interface{}) error {
+	return validationRules.Validate(m)
+}
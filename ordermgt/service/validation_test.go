@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	wrapper "github.com/golang/protobuf/ptypes/wrappers"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	pb "ordergmt/service/ecommerce"
+)
+
+// Get the violated fields from the BadRequest details of the error.
+func violatedFields(t *testing.T, err error) []string {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+	var fields []string
+	for _, d := range st.Details() {
+		if badRequest, ok := d.(*epb.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+		}
+	}
+	return fields
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		order *pb.Order
+		want  []string
+	}{
+		{"valid", &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)}, nil},
+		{"street address", &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "1 Infinite Loop, Cupertino, CA 95014", Price: amount.New("USD", 1300, 0)}, nil},
+		{"all invalid", &pb.Order{Id: "-1", Destination: "San Jose"}, []string{"id", "items", "price", "destination"}},
+		{"blank item", &pb.Order{Id: "101", Items: []string{"iPhone XS", " "}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, 0)}, []string{"items[1]"}},
+		{"empty", &pb.Order{}, []string{"id", "items", "price", "destination"}},
+		{"bad currency", &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("usd", 1300, 0)}, []string{"price"}},
+		{"mixed signs", &pb.Order{Id: "101", Items: []string{"iPhone XS"}, Destination: "San Jose, CA", Price: amount.New("USD", 1300, -500000000)}, []string{"price"}},
+	}
+	for _, tt := range tests {
+		err := validate(tt.order)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: validate() error: %v", tt.name, err)
+			}
+			continue
+		}
+		if got := violatedFields(t, err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: validate() violated fields = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOrderMgtServer_ValidationInterceptors(t *testing.T) {
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, newMemoryOrderStore()),
+		grpc.UnaryInterceptor(orderUnaryServerInterceptor),
+		grpc.StreamInterceptor(orderServerStreamInterceptor))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err := client.AddOrder(ctx, &pb.Order{Id: "-1", Items: []string{"iPhone XS"}, Destination: "San Jose, CA"})
+	if got, want := violatedFields(t, err), []string{"id", "price"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AddOrder() violated fields = %v, want %v", got, want)
+	}
+
+}
+
+// An invalid order of UpdateOrders is reported in its result, the orders around it are still updated.
+func TestOrderMgtServer_UpdateOrdersInvalidOrder(t *testing.T) {
+	store := newMemoryOrderStore()
+	if err := initSampleData(store); err != nil {
+		t.Fatalf("initSampleData() error: %v", err)
+	}
+	client, stop := startOrderMgtServer(t, newTestOrderMgtServer(t, store),
+		grpc.UnaryInterceptor(orderUnaryServerInterceptor),
+		grpc.StreamInterceptor(orderServerStreamInterceptor))
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	stream, err := client.UpdateOrders(ctx)
+	if err != nil {
+		t.Fatalf("UpdateOrders() error: %v", err)
+	}
+	for _, order := range []*pb.Order{
+		{Id: "102", Items: []string{"Google Pixel 3A"}, Destination: "Mountain View, CA", Price: amount.New("USD", 300, 0)},
+		{Id: "103", Items: []string{"Apple Watch S4"}, Destination: "San Jose", Price: amount.New("USD", 450, 0)},
+		{Id: "104", Items: []string{"Google Home Mini"}, Destination: "Mountain View, CA", Price: amount.New("USD", 50, 0)},
+	} {
+		if err := stream.Send(order); err != nil {
+			t.Fatalf("Send(%s) error: %v", order.Id, err)
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("UpdateOrders() error: %v", err)
+	}
+	if len(res.Results) != 3 {
+		t.Fatalf("UpdateOrders() got %d results, want 3", len(res.Results))
+	}
+	for i, want := range []codes.Code{codes.OK, codes.InvalidArgument, codes.OK} {
+		if got := codes.Code(res.Results[i].Status.Code); got != want {
+			t.Errorf("result of %s = %v, want %v", res.Results[i].OrderId, got, want)
+		}
+	}
+	st := status.FromProto(res.Results[1].Status)
+	if got, want := violatedFields(t, st.Err()), []string{"destination"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("violated fields of 103 = %v, want %v", got, want)
+	}
+	for id, want := range map[string]int64{"102": 300, "103": 400, "104": 50} {
+		if order, err := client.GetOrder(ctx, &wrapper.StringValue{Value: id}); err != nil || order.Price.GetUnits() != want {
+			t.Errorf("GetOrder(%s) = %v, %v, want the price %d", id, order, err, want)
+		}
+	}
+}
@@ -7,6 +7,9 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	money "google.golang.org/genproto/googleapis/type/money"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -25,13 +28,13 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type Product struct {
-	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description          string   `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Price                float32  `protobuf:"fixed32,4,opt,name=price,proto3" json:"price,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Id                   string       `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string       `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description          string       `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price                *money.Money `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *Product) Reset()         { *m = Product{} }
@@ -80,11 +83,11 @@ func (m *Product) GetDescription() string {
 	return ""
 }
 
-func (m *Product) GetPrice() float32 {
+func (m *Product) GetPrice() *money.Money {
 	if m != nil {
 		return m.Price
 	}
-	return 0
+	return nil
 }
 
 type ProductID struct {
@@ -126,28 +129,196 @@ func (m *ProductID) GetValue() string {
 	return ""
 }
 
+type UpdateProductRequest struct {
+	Product              *Product              `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	UpdateMask           *field_mask.FieldMask `protobuf:"bytes,2,opt,name=updateMask,proto3" json:"updateMask,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *UpdateProductRequest) Reset()         { *m = UpdateProductRequest{} }
+func (m *UpdateProductRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateProductRequest) ProtoMessage()    {}
+func (*UpdateProductRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a4d768ec9cb4951, []int{2}
+}
+
+func (m *UpdateProductRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateProductRequest.Unmarshal(m, b)
+}
+func (m *UpdateProductRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateProductRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdateProductRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateProductRequest.Merge(m, src)
+}
+func (m *UpdateProductRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateProductRequest.Size(m)
+}
+func (m *UpdateProductRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateProductRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateProductRequest proto.InternalMessageInfo
+
+func (m *UpdateProductRequest) GetProduct() *Product {
+	if m != nil {
+		return m.Product
+	}
+	return nil
+}
+
+func (m *UpdateProductRequest) GetUpdateMask() *field_mask.FieldMask {
+	if m != nil {
+		return m.UpdateMask
+	}
+	return nil
+}
+
+type ListProductsRequest struct {
+	PageSize             int32    `protobuf:"varint,1,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageToken            string   `protobuf:"bytes,2,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	OrderBy              string   `protobuf:"bytes,3,opt,name=orderBy,proto3" json:"orderBy,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListProductsRequest) Reset()         { *m = ListProductsRequest{} }
+func (m *ListProductsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListProductsRequest) ProtoMessage()    {}
+func (*ListProductsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a4d768ec9cb4951, []int{3}
+}
+
+func (m *ListProductsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListProductsRequest.Unmarshal(m, b)
+}
+func (m *ListProductsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListProductsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListProductsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListProductsRequest.Merge(m, src)
+}
+func (m *ListProductsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListProductsRequest.Size(m)
+}
+func (m *ListProductsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListProductsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListProductsRequest proto.InternalMessageInfo
+
+func (m *ListProductsRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListProductsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *ListProductsRequest) GetOrderBy() string {
+	if m != nil {
+		return m.OrderBy
+	}
+	return ""
+}
+
+type ListProductsResponse struct {
+	Products             []*Product `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	NextPageToken        string     `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ListProductsResponse) Reset()         { *m = ListProductsResponse{} }
+func (m *ListProductsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListProductsResponse) ProtoMessage()    {}
+func (*ListProductsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9a4d768ec9cb4951, []int{4}
+}
+
+func (m *ListProductsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListProductsResponse.Unmarshal(m, b)
+}
+func (m *ListProductsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListProductsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListProductsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListProductsResponse.Merge(m, src)
+}
+func (m *ListProductsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListProductsResponse.Size(m)
+}
+func (m *ListProductsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListProductsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListProductsResponse proto.InternalMessageInfo
+
+func (m *ListProductsResponse) GetProducts() []*Product {
+	if m != nil {
+		return m.Products
+	}
+	return nil
+}
+
+func (m *ListProductsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Product)(nil), "ecommerce.Product")
 	proto.RegisterType((*ProductID)(nil), "ecommerce.ProductID")
+	proto.RegisterType((*UpdateProductRequest)(nil), "ecommerce.UpdateProductRequest")
+	proto.RegisterType((*ListProductsRequest)(nil), "ecommerce.ListProductsRequest")
+	proto.RegisterType((*ListProductsResponse)(nil), "ecommerce.ListProductsResponse")
 }
 
 func init() { proto.RegisterFile("product_info.proto", fileDescriptor_9a4d768ec9cb4951) }
 
 var fileDescriptor_9a4d768ec9cb4951 = []byte{
-	// 199 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x2a, 0x28, 0xca, 0x4f,
-	0x29, 0x4d, 0x2e, 0x89, 0xcf, 0xcc, 0x4b, 0xcb, 0xd7, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2,
-	0x4c, 0x4d, 0xce, 0xcf, 0xcd, 0x4d, 0x2d, 0x4a, 0x4e, 0x55, 0x4a, 0xe5, 0x62, 0x0f, 0x80, 0x28,
-	0x10, 0xe2, 0xe3, 0x62, 0xca, 0x4c, 0x91, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0x62, 0xca, 0x4c,
-	0x11, 0x12, 0xe2, 0x62, 0xc9, 0x4b, 0xcc, 0x4d, 0x95, 0x60, 0x02, 0x8b, 0x80, 0xd9, 0x42, 0x0a,
-	0x5c, 0xdc, 0x29, 0xa9, 0xc5, 0xc9, 0x45, 0x99, 0x05, 0x25, 0x99, 0xf9, 0x79, 0x12, 0xcc, 0x60,
-	0x29, 0x64, 0x21, 0x21, 0x11, 0x2e, 0xd6, 0x82, 0xa2, 0xcc, 0xe4, 0x54, 0x09, 0x16, 0x05, 0x46,
-	0x0d, 0xa6, 0x20, 0x08, 0x47, 0x49, 0x91, 0x8b, 0x13, 0x6a, 0x8d, 0xa7, 0x0b, 0x48, 0x49, 0x59,
-	0x62, 0x4e, 0x69, 0x2a, 0xd4, 0x2e, 0x08, 0xc7, 0xa8, 0x96, 0x8b, 0x1b, 0xa6, 0x24, 0x2f, 0x2d,
-	0x5f, 0xc8, 0x8c, 0x8b, 0x2b, 0x31, 0x25, 0x05, 0xe6, 0x36, 0x21, 0x3d, 0xb8, 0x93, 0xf5, 0xa0,
-	0x62, 0x52, 0x22, 0x98, 0x62, 0x9e, 0x2e, 0x20, 0x7d, 0xe9, 0xa9, 0x25, 0x30, 0x7d, 0x58, 0xd5,
-	0x48, 0x61, 0x31, 0x2d, 0x89, 0x0d, 0x1c, 0x34, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff, 0x9b,
-	0x1f, 0xc5, 0x58, 0x30, 0x01, 0x00, 0x00,
+	// 452 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x93, 0xc1, 0x8b, 0xd3, 0x40,
+	0x14, 0xc6, 0x49, 0xb7, 0xb1, 0xed, 0xab, 0x15, 0x79, 0x16, 0x0d, 0x51, 0xdc, 0x18, 0x3c, 0xf4,
+	0x20, 0x29, 0x54, 0xf0, 0x20, 0x78, 0x91, 0x2a, 0xac, 0xb8, 0xb8, 0x44, 0x3d, 0x2f, 0xd9, 0xcc,
+	0x6b, 0x19, 0x9a, 0x64, 0xc6, 0xcc, 0x44, 0xac, 0x17, 0xfd, 0x1f, 0xfd, 0x87, 0x24, 0xc9, 0x4c,
+	0xcd, 0xee, 0xa6, 0xde, 0x32, 0xef, 0xfd, 0xe6, 0x7d, 0xdf, 0x7c, 0x8f, 0x00, 0xca, 0x52, 0xb0,
+	0x2a, 0xd5, 0x97, 0xbc, 0xd8, 0x88, 0x48, 0x96, 0x42, 0x0b, 0x9c, 0x50, 0x2a, 0xf2, 0x9c, 0xca,
+	0x94, 0xfc, 0xc7, 0x5b, 0x21, 0xb6, 0x19, 0x2d, 0x9b, 0xc6, 0x55, 0xb5, 0x59, 0x52, 0x2e, 0xf5,
+	0xbe, 0xe5, 0xfc, 0xe0, 0x66, 0x73, 0xc3, 0x29, 0x63, 0x97, 0x79, 0xa2, 0x76, 0x86, 0x78, 0x64,
+	0x08, 0xbd, 0x97, 0xb4, 0xcc, 0x45, 0x41, 0xe6, 0x6a, 0xf8, 0x0b, 0x46, 0x17, 0xad, 0x30, 0xde,
+	0x83, 0x01, 0x67, 0x9e, 0x13, 0x38, 0x8b, 0x49, 0x3c, 0xe0, 0x0c, 0x11, 0x86, 0x45, 0x92, 0x93,
+	0x37, 0x68, 0x2a, 0xcd, 0x37, 0x06, 0x30, 0x65, 0xa4, 0xd2, 0x92, 0x4b, 0xcd, 0x45, 0xe1, 0x9d,
+	0x34, 0xad, 0x6e, 0x09, 0x17, 0xe0, 0xca, 0x92, 0xa7, 0xe4, 0xb9, 0x81, 0xb3, 0x98, 0xae, 0x30,
+	0x6a, 0x95, 0xa3, 0x5a, 0x39, 0x3a, 0xaf, 0x95, 0xe3, 0x16, 0xf8, 0x30, 0x1c, 0x0f, 0xef, 0xbb,
+	0xe1, 0x33, 0x98, 0x18, 0x03, 0x67, 0x6b, 0x9c, 0x83, 0xfb, 0x3d, 0xc9, 0x2a, 0x32, 0x2e, 0xda,
+	0x43, 0xf8, 0xdb, 0x81, 0xf9, 0x57, 0xc9, 0x12, 0x4d, 0x86, 0x8c, 0xe9, 0x5b, 0x45, 0x4a, 0xe3,
+	0x0b, 0x18, 0x99, 0xd4, 0x3c, 0xc7, 0xa8, 0x1d, 0x12, 0x8b, 0x2c, 0x6b, 0x11, 0x7c, 0x0d, 0x50,
+	0x35, 0x53, 0xce, 0x13, 0xb5, 0x6b, 0x5e, 0x35, 0x5d, 0xf9, 0xd6, 0x9e, 0x8d, 0x2e, 0x7a, 0x5f,
+	0x47, 0x57, 0x13, 0x71, 0x87, 0x0e, 0x39, 0x3c, 0xf8, 0xc8, 0x95, 0x36, 0x33, 0x95, 0x35, 0xe0,
+	0xc3, 0x58, 0x26, 0x5b, 0xfa, 0xcc, 0x7f, 0xb6, 0x96, 0xdd, 0xf8, 0x70, 0xc6, 0x27, 0x30, 0xa9,
+	0xbf, 0xbf, 0x88, 0x1d, 0x15, 0x26, 0xc3, 0x7f, 0x05, 0xf4, 0x60, 0x24, 0x4a, 0x46, 0xe5, 0xdb,
+	0xbd, 0x09, 0xd1, 0x1e, 0xc3, 0x0c, 0xe6, 0xd7, 0xa5, 0x94, 0x14, 0x85, 0x22, 0x8c, 0x60, 0x6c,
+	0x5e, 0xa2, 0x3c, 0x27, 0x38, 0x39, 0xf2, 0xda, 0x03, 0x83, 0xcf, 0x61, 0x56, 0xd0, 0x0f, 0x7d,
+	0x71, 0xc3, 0xc3, 0xf5, 0xe2, 0xea, 0xcf, 0x00, 0xa6, 0x36, 0xff, 0x62, 0x23, 0xf0, 0x15, 0x40,
+	0xc2, 0x98, 0xa9, 0x60, 0x8f, 0x82, 0x3f, 0xbf, 0x5d, 0x3b, 0x5b, 0xd7, 0xf7, 0xb6, 0x64, 0x4d,
+	0x63, 0x2f, 0xe3, 0xf7, 0x4c, 0xc3, 0x35, 0xcc, 0xaa, 0xee, 0x6a, 0xf1, 0xb4, 0x03, 0xf5, 0x2d,
+	0xbd, 0x77, 0xca, 0x1b, 0x98, 0x31, 0xca, 0x48, 0xd3, 0xff, 0x0d, 0x3c, 0xbc, 0xb5, 0xed, 0x77,
+	0xf5, 0x5f, 0x84, 0x9f, 0xe0, 0x6e, 0xd6, 0x89, 0x1c, 0x9f, 0x76, 0x6e, 0xf7, 0xac, 0xdd, 0x3f,
+	0x3d, 0xda, 0x6f, 0x77, 0x75, 0x75, 0xa7, 0x11, 0x78, 0xf9, 0x77, 0x00, 0x79, 0x82, 0x59, 0x5e,
+	0xd5, 0x03, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -164,6 +335,9 @@ const _ = grpc.SupportPackageIsVersion6
 type ProductInfoClient interface {
 	AddProduct(ctx context.Context, in *Product, opts ...grpc.CallOption) (*ProductID, error)
 	GetProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*Product, error)
+	UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*Product, error)
+	DeleteProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*empty.Empty, error)
+	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error)
 }
 
 type productInfoClient struct {
@@ -192,10 +366,40 @@ func (c *productInfoClient) GetProduct(ctx context.Context, in *ProductID, opts
 	return out, nil
 }
 
+func (c *productInfoClient) UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*Product, error) {
+	out := new(Product)
+	err := c.cc.Invoke(ctx, "/ecommerce.ProductInfo/updateProduct", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) DeleteProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/ecommerce.ProductInfo/deleteProduct", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error) {
+	out := new(ListProductsResponse)
+	err := c.cc.Invoke(ctx, "/ecommerce.ProductInfo/listProducts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProductInfoServer is the server API for ProductInfo service.
 type ProductInfoServer interface {
 	AddProduct(context.Context, *Product) (*ProductID, error)
 	GetProduct(context.Context, *ProductID) (*Product, error)
+	UpdateProduct(context.Context, *UpdateProductRequest) (*Product, error)
+	DeleteProduct(context.Context, *ProductID) (*empty.Empty, error)
+	ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error)
 }
 
 // UnimplementedProductInfoServer can be embedded to have forward compatible implementations.
@@ -208,6 +412,15 @@ func (*UnimplementedProductInfoServer) AddProduct(ctx context.Context, req *Prod
 func (*UnimplementedProductInfoServer) GetProduct(ctx context.Context, req *ProductID) (*Product, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProduct not implemented")
 }
+func (*UnimplementedProductInfoServer) UpdateProduct(ctx context.Context, req *UpdateProductRequest) (*Product, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProduct not implemented")
+}
+func (*UnimplementedProductInfoServer) DeleteProduct(ctx context.Context, req *ProductID) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProduct not implemented")
+}
+func (*UnimplementedProductInfoServer) ListProducts(ctx context.Context, req *ListProductsRequest) (*ListProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
+}
 
 func RegisterProductInfoServer(s *grpc.Server, srv ProductInfoServer) {
 	s.RegisterService(&_ProductInfo_serviceDesc, srv)
@@ -249,6 +462,60 @@ func _ProductInfo_GetProduct_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProductInfo_UpdateProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).UpdateProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.ProductInfo/UpdateProduct",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).UpdateProduct(ctx, req.(*UpdateProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_DeleteProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).DeleteProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.ProductInfo/DeleteProduct",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).DeleteProduct(ctx, req.(*ProductID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).ListProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.ProductInfo/ListProducts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).ListProducts(ctx, req.(*ListProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ProductInfo_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ecommerce.ProductInfo",
 	HandlerType: (*ProductInfoServer)(nil),
@@ -261,6 +528,18 @@ var _ProductInfo_serviceDesc = grpc.ServiceDesc{
 			MethodName: "getProduct",
 			Handler:    _ProductInfo_GetProduct_Handler,
 		},
+		{
+			MethodName: "updateProduct",
+			Handler:    _ProductInfo_UpdateProduct_Handler,
+		},
+		{
+			MethodName: "deleteProduct",
+			Handler:    _ProductInfo_DeleteProduct_Handler,
+		},
+		{
+			MethodName: "listProducts",
+			Handler:    _ProductInfo_ListProducts_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "product_info.proto",
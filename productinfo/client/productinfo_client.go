@@ -5,7 +5,10 @@ import (
 	"log"
 	"time"
 
+	money "google.golang.org/genproto/googleapis/type/money"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	pb "productinfo/client/ecommerce"
 )
 
@@ -26,20 +29,60 @@ func main() {
 	// Create a new product
 	name := "Apple iPhone 11"
 	description := `Meet Apple iPhone 11. All-new dual-camera system with Ultra Wide and Night mode.`
-	price := float32(1000.0)
+	price := &money.Money{CurrencyCode: "USD", Units: 1000} // 1000.00 USD, exact units + nanos instead of float.
 
 	// Add a new product
-	r, err := c.AddProduct(ctx, &pb.Product{Name: name, Description: description, Price: price})
+	// The idempotency key makes the retries of the call get the same Product ID instead of adding duplicates.
+	addCtx := metadata.AppendToOutgoingContext(ctx, "idempotency-key", "add-apple-iphone-11")
+	r, err := c.AddProduct(addCtx, &pb.Product{Name: name, Description: description, Price: price})
 	if err != nil {
 		log.Fatalf("Could not add product: %v", err)
 	}
 	log.Printf("Product ID: %s added successfully", r.Value)
 
+	// Retry adding the product with the same idempotency key
+	retried, err := c.AddProduct(addCtx, &pb.Product{Name: name, Description: description, Price: price})
+	if err != nil {
+		log.Fatalf("Could not add product: %v", err)
+	}
+	log.Printf("Product ID: %s returned by the retry", retried.Value)
+
 	// Get a product
 	product, err := c.GetProduct(ctx, &pb.ProductID{Value: r.Value})
 	if err != nil {
 		log.Fatalf("Could not get product: %v", err)
 	}
-	log.Printf("Product: ", product.String())
+	log.Printf("Product: %s", product.String())
+
+	// Update the price of a product only, the other fields are kept
+	updatedProduct, err := c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: product.Id, Price: &money.Money{CurrencyCode: "USD", Units: 899, Nanos: 990000000}},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"price"}},
+	})
+	if err != nil {
+		log.Fatalf("Could not update product: %v", err)
+	}
+	log.Printf("Product: %s updated successfully", updatedProduct.String())
 
+	// List products by page
+	listReq := &pb.ListProductsRequest{PageSize: 10, OrderBy: "price desc"}
+	for {
+		listRes, err := c.ListProducts(ctx, listReq)
+		if err != nil {
+			log.Fatalf("Could not list products: %v", err)
+		}
+		for _, p := range listRes.Products {
+			log.Printf("Product: %s", p.String())
+		}
+		if listRes.NextPageToken == "" {
+			break
+		}
+		listReq.PageToken = listRes.NextPageToken
+	}
+
+	// Delete a product
+	if _, err := c.DeleteProduct(ctx, &pb.ProductID{Value: r.Value}); err != nil {
+		log.Fatalf("Could not delete product: %v", err)
+	}
+	log.Printf("Product ID: %s deleted successfully", r.Value)
 }
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net"
 
 	"google.golang.org/grpc"
+	"grpc-up-and-running/common/idempotency"
+	"grpc-up-and-running/common/interceptor"
+	"grpc-up-and-running/common/metrics"
 	pb "productinfo/service/ecommerce"
 )
 
@@ -12,16 +16,35 @@ const (
 	port = ":50051"
 )
 
+var (
+	idempotencyTTL = flag.Duration("idempotency-ttl", idempotency.DefaultTTL, "How long the results of AddProduct are remembered for the retries with the same idempotency key")
+	metricsAddr    = flag.String("metrics-addr", ":9090", "The address of the HTTP server serving the metrics on /metrics, empty disables it")
+)
+
 func main() {
+	flag.Parse()
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterProductInfoServer(s, &server{})
+	serverMetrics := metrics.NewServerMetrics()
+	s := newServer(serverMetrics)
+	if *metricsAddr != "" {
+		metrics.Serve(*metricsAddr, serverMetrics)
+	}
 
 	log.Printf("Starting gRPC listener on port " + port)
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
 }
+
+// Create the gRPC server with the ProductInfo service registered, the calls are recorded in the metrics.
+func newServer(serverMetrics *metrics.ServerMetrics) *grpc.Server {
+	s := grpc.NewServer(
+		// Register unary interceptors.
+		grpc.UnaryInterceptor(interceptor.ChainUnaryServer(serverMetrics.UnaryInterceptor, validationUnaryServerInterceptor)),
+		grpc.StreamInterceptor(serverMetrics.StreamInterceptor))
+	pb.RegisterProductInfoServer(s, &server{idempotency: idempotency.NewCache(*idempotencyTTL)})
+	return s
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "productinfo/service/ecommerce"
+)
+
+// The fields of Product which can be updated, by their paths in the update mask.
+// The product ID identifies the product, it can't be updated.
+var productMaskFields = map[string]func(dst, src *pb.Product){
+	"name":        func(dst, src *pb.Product) { dst.Name = src.Name },
+	"description": func(dst, src *pb.Product) { dst.Description = src.Description },
+	"price":       func(dst, src *pb.Product) { dst.Price = src.Price },
+}
+
+// Copy the fields in the update mask from src to dst, all the fields are copied if the mask is empty.
+// Returns an InvalidArgument error with the BadRequest details listing the unknown paths, nothing is copied in this case.
+func applyProductMask(dst, src *pb.Product, mask *field_mask.FieldMask) error {
+	if len(mask.GetPaths()) == 0 {
+		for _, copyField := range productMaskFields {
+			copyField(dst, src)
+		}
+		return nil
+	}
+
+	var violations []*epb.BadRequest_FieldViolation
+	for i, path := range mask.Paths {
+		if _, ok := productMaskFields[path]; !ok {
+			violations = append(violations, &epb.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("updateMask.paths[%d]", i),
+				Description: fmt.Sprintf("unknown or immutable field %q", path),
+			})
+		}
+	}
+	if len(violations) > 0 {
+		errorStatus := status.New(codes.InvalidArgument, "Invalid update mask")
+		ds, err := errorStatus.WithDetails(&epb.BadRequest{FieldViolations: violations})
+		if err != nil {
+			return errorStatus.Err()
+		}
+		return ds.Err()
+	}
+
+	for _, path := range mask.Paths {
+		productMaskFields[path](dst, src)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	pb "productinfo/service/ecommerce"
+)
+
+// productStore keeps the products in a copy-on-write map.
+// The readers load the current snapshot of the map without locking,
+// the writers are serialized, each of them copies the snapshot, modifies the copy and publishes it as the new snapshot.
+// The product catalog is read much more often than it is written, and ListProducts needs a consistent view of all the products.
+// The zero value is an empty store ready to use.
+type productStore struct {
+	mu       sync.Mutex   // Serializes the writers.
+	snapshot atomic.Value // The current map[string]*pb.Product, never modified after being published.
+}
+
+// Load the current snapshot of the products, the caller must not modify it.
+func (s *productStore) load() map[string]*pb.Product {
+	products, _ := s.snapshot.Load().(map[string]*pb.Product)
+	return products
+}
+
+// Copy the current snapshot, apply the update on the copy and publish it.
+// If update returns false, the copy is discarded.
+func (s *productStore) update(update func(products map[string]*pb.Product) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.load()
+	next := make(map[string]*pb.Product, len(current)+1)
+	for id, product := range current {
+		next[id] = product
+	}
+	if !update(next) {
+		return false
+	}
+	s.snapshot.Store(next)
+	return true
+}
+
+// Get a copy of the product by product ID.
+func (s *productStore) Get(id string) (*pb.Product, bool) {
+	product, exists := s.load()[id]
+	if !exists {
+		return nil, false
+	}
+	return proto.Clone(product).(*pb.Product), true
+}
+
+// Add a product, returns false if the product ID already exists.
+func (s *productStore) Add(product *pb.Product) bool {
+	product = proto.Clone(product).(*pb.Product)
+	return s.update(func(products map[string]*pb.Product) bool {
+		if _, exists := products[product.Id]; exists {
+			return false
+		}
+		products[product.Id] = product
+		return true
+	})
+}
+
+// Update an existing product by fn on a copy of it, returns a copy of the updated product.
+// Returns false if the product does not exist.
+// If fn returns an error, the product is left unchanged and the error is returned.
+func (s *productStore) Update(id string, fn func(product *pb.Product) error) (*pb.Product, bool, error) {
+	var updated *pb.Product
+	var err error
+	exists := true
+	s.update(func(products map[string]*pb.Product) bool {
+		current, ok := products[id]
+		if !ok {
+			exists = false
+			return false
+		}
+		product := proto.Clone(current).(*pb.Product)
+		if err = fn(product); err != nil {
+			return false
+		}
+		products[id] = product
+		updated = proto.Clone(product).(*pb.Product)
+		return true
+	})
+	return updated, exists, err
+}
+
+// Delete a product by product ID, returns false if the product does not exist.
+func (s *productStore) Delete(id string) bool {
+	return s.update(func(products map[string]*pb.Product) bool {
+		if _, exists := products[id]; !exists {
+			return false
+		}
+		delete(products, id)
+		return true
+	})
+}
+
+// List copies of all the products in the current snapshot.
+func (s *productStore) List() []*pb.Product {
+	snapshot := s.load()
+	products := make([]*pb.Product, 0, len(snapshot))
+	for _, product := range snapshot {
+		products = append(products, proto.Clone(product).(*pb.Product))
+	}
+	return products
+}
@@ -0,0 +1,323 @@
+// Test for AddProduct by bufconn
+// bufconn can avoid the server to open up a port the client connects to.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"grpc-up-and-running/common/amount"
+	"grpc-up-and-running/common/idempotency"
+	"grpc-up-and-running/common/metrics"
+	"log"
+	"net"
+	pb "productinfo/service/ecommerce"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	bufSize = 1024 * 1024
+)
+
+var listener *bufconn.Listener
+
+func getBufDialer(listener *bufconn.Listener) func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, url string) (net.Conn, error) {
+		return listener.Dial()
+	}
+}
+
+// Initialization of BufConn.
+// Package bufconn provides a net.Conn implemented by a buffer and related dialing and listening functionality.
+func initGRPCServerBuffConn() {
+	listener = bufconn.Listen(bufSize)
+	s := newServer(metrics.NewServerMetrics())
+	// Register reflection server on gRPC server.
+	reflection.Register(s)
+	go func() {
+		if err := s.Serve(listener); err != nil {
+			log.Fatalf("failed to serve: %v", err)
+		}
+	}()
+
+}
+
+// Test AddProduct using Buffconn
+func TestServer_AddProductBufConn(t *testing.T) {
+	ctx := context.Background()
+	initGRPCServerBuffConn()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(getBufDialer(listener)), grpc.WithInsecure())
+	if err != nil {
+		log.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+	c := pb.NewProductInfoClient(conn)
+
+	// Contact the server and print out its response.
+	name := "Sumsung S10"
+	description := "Samsung Galaxy S10 is the latest smart phone, launched in February 2019"
+	price := amount.New("USD", 700, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r, err := c.AddProduct(ctx, &pb.Product{Name: name, Description: description, Price: price})
+	if err != nil {
+		log.Fatalf("Could not add product: %v", err)
+	}
+	log.Printf(r.Value)
+}
+
+// Test UpdateProduct, DeleteProduct and ListProducts using Buffconn
+func TestServer_ListProductsBufConn(t *testing.T) {
+	ctx := context.Background()
+	initGRPCServerBuffConn()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(getBufDialer(listener)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+	c := pb.NewProductInfoClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Add 5 products with the price from 100 to 500.
+	ids := make([]string, 0, 5)
+	for i := 1; i <= 5; i++ {
+		r, err := c.AddProduct(ctx, &pb.Product{Name: fmt.Sprintf("Product %d", i), Price: amount.New("USD", int64(i*100), 0)})
+		if err != nil {
+			t.Fatalf("Could not add product: %v", err)
+		}
+		ids = append(ids, r.Value)
+	}
+
+	// Update the price of the first product, so that it becomes the most expensive one.
+	updated, err := c.UpdateProduct(ctx, &pb.UpdateProductRequest{Product: &pb.Product{Id: ids[0], Name: "Product 1", Price: amount.New("USD", 600, 0)}})
+	if err != nil {
+		t.Fatalf("Could not update product: %v", err)
+	}
+	if updated.Price.GetUnits() != 600 {
+		t.Errorf("UpdateProduct() price = %v, want 600", updated.Price)
+	}
+
+	// Delete the second product.
+	if _, err := c.DeleteProduct(ctx, &pb.ProductID{Value: ids[1]}); err != nil {
+		t.Fatalf("Could not delete product: %v", err)
+	}
+	if _, err := c.GetProduct(ctx, &pb.ProductID{Value: ids[1]}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetProduct() on deleted product got %v, want NotFound", err)
+	}
+
+	// List the remaining products by price in descending order, 2 products per page.
+	var prices []int64
+	req := &pb.ListProductsRequest{PageSize: 2, OrderBy: "price desc"}
+	for {
+		res, err := c.ListProducts(ctx, req)
+		if err != nil {
+			t.Fatalf("Could not list products: %v", err)
+		}
+		for _, product := range res.Products {
+			prices = append(prices, product.Price.GetUnits())
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		req.PageToken = res.NextPageToken
+	}
+	want := []int64{600, 500, 400, 300}
+	if !reflect.DeepEqual(prices, want) {
+		t.Errorf("ListProducts() prices = %v, want %v", prices, want)
+	}
+
+	if _, err := c.ListProducts(ctx, &pb.ListProductsRequest{OrderBy: "weight"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListProducts() with unknown field got %v, want InvalidArgument", err)
+	}
+}
+
+// Test the pages of ListProducts don't skip or repeat the products when a product is added between the pages
+func TestServer_ListProductsInsertBetweenPagesBufConn(t *testing.T) {
+	ctx := context.Background()
+	initGRPCServerBuffConn()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(getBufDialer(listener)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+	c := pb.NewProductInfoClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for _, name := range []string{"Product B", "Product D", "Product F"} {
+		if _, err := c.AddProduct(ctx, &pb.Product{Name: name, Price: amount.New("USD", 100, 0)}); err != nil {
+			t.Fatalf("Could not add product: %v", err)
+		}
+	}
+	req := &pb.ListProductsRequest{PageSize: 2, OrderBy: "name"}
+	res, err := c.ListProducts(ctx, req)
+	if err != nil {
+		t.Fatalf("Could not list products: %v", err)
+	}
+	var names []string
+	for _, product := range res.Products {
+		names = append(names, product.Name)
+	}
+
+	// A product sorted before the returned page is added, it would shift the next page by an offset.
+	if _, err := c.AddProduct(ctx, &pb.Product{Name: "Product A", Price: amount.New("USD", 100, 0)}); err != nil {
+		t.Fatalf("Could not add product: %v", err)
+	}
+	req.PageToken = res.NextPageToken
+	if res, err = c.ListProducts(ctx, req); err != nil {
+		t.Fatalf("Could not list products: %v", err)
+	}
+	for _, product := range res.Products {
+		names = append(names, product.Name)
+	}
+	want := []string{"Product B", "Product D", "Product F"}
+	if !reflect.DeepEqual(names, want) || res.NextPageToken != "" {
+		t.Errorf("ListProducts() names = %v, next page token %q, want %v and no more page", names, res.NextPageToken, want)
+	}
+
+	if _, err := c.ListProducts(ctx, &pb.ListProductsRequest{PageToken: "not a token"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListProducts() with invalid page token got %v, want InvalidArgument", err)
+	}
+}
+
+// Test the validation of Product using Buffconn
+func TestServer_AddProductValidationBufConn(t *testing.T) {
+	ctx := context.Background()
+	initGRPCServerBuffConn()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(getBufDialer(listener)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+	c := pb.NewProductInfoClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// All the invalid fields should be reported in one response.
+	_, err = c.AddProduct(ctx, &pb.Product{Name: " ", Price: amount.New("USD", -1, 0)})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("AddProduct() got %v, want InvalidArgument", err)
+	}
+	var fields []string
+	for _, d := range st.Details() {
+		if badRequest, ok := d.(*epb.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+		}
+	}
+	if want := []string{"name", "price"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("AddProduct() violated fields = %v, want %v", fields, want)
+	}
+}
+
+// Test the retries of AddProduct with the same idempotency key using Buffconn
+func TestServer_AddProductIdempotentBufConn(t *testing.T) {
+	ctx := context.Background()
+	initGRPCServerBuffConn()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(getBufDialer(listener)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+	c := pb.NewProductInfoClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, idempotency.KeyHeader, "add-pixel")
+
+	// The retries should get the same Product ID without adding the product again.
+	product := &pb.Product{Name: "Google Pixel 3A", Price: amount.New("USD", 550, 0)}
+	var ids []string
+	for i := 0; i < 3; i++ {
+		r, err := c.AddProduct(ctx, product)
+		if err != nil {
+			t.Fatalf("Could not add product: %v", err)
+		}
+		ids = append(ids, r.Value)
+	}
+	if ids[1] != ids[0] || ids[2] != ids[0] {
+		t.Errorf("AddProduct() retries got IDs %v, want the same ID", ids)
+	}
+	res, err := c.ListProducts(ctx, &pb.ListProductsRequest{})
+	if err != nil {
+		t.Fatalf("Could not list products: %v", err)
+	}
+	if len(res.Products) != 1 {
+		t.Errorf("ListProducts() got %d products, want 1", len(res.Products))
+	}
+
+	// The same key can't be reused by a different product.
+	if _, err := c.AddProduct(ctx, &pb.Product{Name: "Apple iPhone 11", Price: amount.New("USD", 700, 0)}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("AddProduct() with a reused key got %v, want InvalidArgument", err)
+	}
+}
+
+// Test the partial update of UpdateProduct by the update mask using Buffconn
+func TestServer_UpdateProductMaskBufConn(t *testing.T) {
+	ctx := context.Background()
+	initGRPCServerBuffConn()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(getBufDialer(listener)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+	c := pb.NewProductInfoClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	r, err := c.AddProduct(ctx, &pb.Product{Name: "Apple iPhone 11", Description: "Dual-camera system", Price: amount.New("USD", 1000, 0)})
+	if err != nil {
+		t.Fatalf("Could not add product: %v", err)
+	}
+
+	// Only the price is changed, the name and the description are kept.
+	updated, err := c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: r.Value, Price: amount.New("USD", 900, 0)},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"price"}},
+	})
+	if err != nil {
+		t.Fatalf("Could not update product: %v", err)
+	}
+	want := &pb.Product{Id: r.Value, Name: "Apple iPhone 11", Description: "Dual-camera system", Price: amount.New("USD", 900, 0)}
+	if !proto.Equal(updated, want) {
+		t.Errorf("UpdateProduct() = %v, want %v", updated, want)
+	}
+
+	// The ID and the unknown fields can't be in the update mask.
+	_, err = c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: r.Value},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"id", "weight"}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateProduct() with unknown paths got %v, want InvalidArgument", err)
+	}
+
+	// The updated product must still be valid.
+	_, err = c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: r.Value},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"name"}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateProduct() with empty name got %v, want InvalidArgument", err)
+	}
+	if product, err := c.GetProduct(ctx, &pb.ProductID{Value: r.Value}); err != nil || !proto.Equal(product, want) {
+		t.Errorf("GetProduct() = %v, %v, want %v", product, err, want)
+	}
+}
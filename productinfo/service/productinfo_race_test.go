@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"grpc-up-and-running/common/amount"
+	pb "productinfo/service/ecommerce"
+)
+
+// Hammer AddProduct together with the readers from many goroutines at the same time.
+// Run with "go test -race" to let the race detector check the product store.
+func TestServer_ConcurrentAddProductBufConn(t *testing.T) {
+	const (
+		workers           = 16
+		productsPerWorker = 50
+	)
+	ctx := context.Background()
+	initGRPCServerBuffConn()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(getBufDialer(listener)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+	c := pb.NewProductInfoClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < productsPerWorker; i++ {
+				r, err := c.AddProduct(ctx, &pb.Product{Name: fmt.Sprintf("Product %d-%d", w, i), Price: amount.New("USD", int64(i+1), 0)})
+				if err != nil {
+					errs <- fmt.Errorf("AddProduct(): %v", err)
+					return
+				}
+				if _, err := c.GetProduct(ctx, r); err != nil {
+					errs <- fmt.Errorf("GetProduct(%s): %v", r.Value, err)
+					return
+				}
+				if _, err := c.ListProducts(ctx, &pb.ListProductsRequest{OrderBy: "price"}); err != nil {
+					errs <- fmt.Errorf("ListProducts(): %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	count := 0
+	req := &pb.ListProductsRequest{PageSize: maxPageSize}
+	for {
+		res, err := c.ListProducts(ctx, req)
+		if err != nil {
+			t.Fatalf("Could not list products: %v", err)
+		}
+		count += len(res.Products)
+		if res.NextPageToken == "" {
+			break
+		}
+		req.PageToken = res.NextPageToken
+	}
+	if want := workers * productsPerWorker; count != want {
+		t.Errorf("ListProducts() got %d products, want %d", count, want)
+	}
+}
@@ -2,42 +2,216 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"grpc-up-and-running/common/amount"
+	"grpc-up-and-running/common/idempotency"
 	pb "productinfo/service/ecommerce"
 )
 
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
 // server is used to implement ecommerce/product_info.
 type server struct {
-	productMap map[string]*pb.Product // Create a map for storing Product records.
+	products productStore // The store of Product records, safe for concurrent RPCs.
+
+	idempotency *idempotency.Cache // The results of AddProduct by the idempotency keys.
 }
 
 // Add a product.
-// This method will generate a new UUID as Product ID and store the Product into a map.
+// This method will generate a new UUID as Product ID and store the Product into the product store.
 // This method will return the Product ID.
+// A retry with the same idempotency key in the metadata gets the original Product ID without adding the product again.
 func (s *server) AddProduct(ctx context.Context, in *pb.Product) (*pb.ProductID, error) {
-	out, err := uuid.NewV4()
+	res, err := s.idempotency.Do(ctx, "AddProduct", in, func() (proto.Message, error) {
+		out, err := uuid.NewV4()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Error while generating Product ID: %v", err)
+		}
+		in.Id = out.String()
+		if !s.products.Add(in) {
+			return nil, status.Errorf(codes.AlreadyExists, "Product already exists: %s", in.Id)
+		}
+		return &pb.ProductID{Value: in.Id}, nil
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Error while generating Product ID", err)
-
+		return nil, err
 	}
-	in.Id = out.String()
-	if s.productMap == nil {
-		s.productMap = make(map[string]*pb.Product)
-	}
-	s.productMap[in.Id] = in
-	return &pb.ProductID{Value: in.Id}, status.New(codes.OK, "").Err()
-
+	return res.(*pb.ProductID), status.New(codes.OK, "").Err()
 }
 
 // Get a product by product ID.
-// This method will check the Product record is existing or not in the map
+// This method will check the Product record is existing or not in the product store.
 func (s *server) GetProduct(ctx context.Context, in *pb.ProductID) (*pb.Product, error) {
-	value, exists := s.productMap[in.Value]
+	value, exists := s.products.Get(in.Value)
 	if exists {
 		return value, status.New(codes.OK, "").Err()
 	}
-	return nil, status.Errorf(codes.NotFound, "Product does not exist.", in.Value)
+	return nil, status.Errorf(codes.NotFound, "Product does not exist: %s", in.Value)
+}
+
+// Update a product.
+// Only the fields in the update mask are changed, the other fields keep the current values even if they are changed concurrently.
+// All the fields are replaced if the update mask is empty.
+// The updated product is validated as a whole, and this method will return the updated Product.
+func (s *server) UpdateProduct(ctx context.Context, in *pb.UpdateProductRequest) (*pb.Product, error) {
+	if in.Product == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Product is required")
+	}
+	product, exists, err := s.products.Update(in.Product.Id, func(product *pb.Product) error {
+		if err := applyProductMask(product, in.Product, in.UpdateMask); err != nil {
+			return err
+		}
+		return validate(product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "Product does not exist: %s", in.Product.Id)
+	}
+	return product, nil
+}
+
+// Delete a product by product ID.
+func (s *server) DeleteProduct(ctx context.Context, in *pb.ProductID) (*empty.Empty, error) {
+	if !s.products.Delete(in.Value) {
+		return nil, status.Errorf(codes.NotFound, "Product does not exist: %s", in.Value)
+	}
+	return &empty.Empty{}, nil
+}
+
+// List products page by page.
+// The products will be sorted by the field in the orderBy of the request (by product ID if not specified).
+// The nextPageToken in the response is used for retrieving the next page, it will be empty on the last page.
+func (s *server) ListProducts(ctx context.Context, in *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	pageSize := int(in.PageSize)
+	if pageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Page size must not be negative: %d", in.PageSize)
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	last, err := decodePageToken(in.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid page token: %s", in.PageToken)
+	}
+
+	less, err := productLessFunc(in.OrderBy)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid order by: %v", err)
+	}
+
+	products := s.products.List()
+	sort.Slice(products, func(i, j int) bool { return less(products[i], products[j]) })
+
+	// The page starts right after the last returned product, so the products added or deleted meanwhile don't shift the pages.
+	start := 0
+	if last != nil {
+		start = sort.Search(len(products), func(i int) bool { return less(last, products[i]) })
+	}
+	end := start + pageSize
+	if end > len(products) {
+		end = len(products)
+	}
+
+	res := &pb.ListProductsResponse{Products: products[start:end]}
+	if end < len(products) {
+		if res.NextPageToken, err = encodePageToken(products[end-1]); err != nil {
+			return nil, status.Errorf(codes.Internal, "Failed to encode page token: %v", err)
+		}
+	}
+	return res, nil
+}
+
+// Build the comparison function for sorting products by the orderBy expression, like "price desc".
+// Products with the same value in the sorting field will be sorted by product ID to keep the order stable between pages.
+func productLessFunc(orderBy string) (func(a, b *pb.Product) bool, error) {
+	fields := strings.Fields(orderBy)
+	field, desc := "id", false
+	switch len(fields) {
+	case 0:
+	case 1:
+		field = fields[0]
+	case 2:
+		if fields[1] != "asc" && fields[1] != "desc" {
+			return nil, fmt.Errorf("unknown direction %q", fields[1])
+		}
+		field, desc = fields[0], fields[1] == "desc"
+	default:
+		return nil, fmt.Errorf("unexpected expression %q", orderBy)
+	}
+
+	var cmp func(a, b *pb.Product) int
+	switch field {
+	case "id":
+		cmp = func(a, b *pb.Product) int { return 0 }
+	case "name":
+		cmp = func(a, b *pb.Product) int { return strings.Compare(a.Name, b.Name) }
+	case "price":
+		// The amounts in different currencies can't be compared, the products are grouped by the currency first.
+		cmp = func(a, b *pb.Product) int {
+			if c := strings.Compare(a.Price.GetCurrencyCode(), b.Price.GetCurrencyCode()); c != 0 {
+				return c
+			}
+			return amount.Compare(a.Price, b.Price)
+		}
+	default:
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+
+	return func(a, b *pb.Product) bool {
+		c := cmp(a, b)
+		if c == 0 {
+			c = strings.Compare(a.Id, b.Id)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	}, nil
+}
+
+// Encode the position of the last returned product into an opaque page token.
+// The position is the product ID with the fields the products can be sorted by, so the token works for any orderBy.
+func encodePageToken(last *pb.Product) (string, error) {
+	b, err := proto.Marshal(&pb.Product{Id: last.Id, Name: last.Name, Price: last.Price})
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// Decode the position of the last returned product from the page token, empty token means the first page (nil).
+func decodePageToken(token string) (*pb.Product, error) {
+	if token == "" {
+		return nil, nil
+	}
+	b, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	last := &pb.Product{}
+	if err := proto.Unmarshal(b, last); err != nil {
+		return nil, err
+	}
+	if last.Id == "" {
+		return nil, fmt.Errorf("no product ID in %q", token)
+	}
+	return last, nil
 }
@@ -7,7 +7,9 @@ import (
 	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
-	pb "grpc-gateway/server/ecommerce"
+	"grpc-up-and-running/common/amount"
+	"grpc-up-and-running/common/metrics"
+	pb "productinfo/service/ecommerce"
 	"testing"
 	"time"
 )
@@ -22,8 +24,7 @@ func initGRPCServerHTTP2() {
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterProductInfoServer(s, &server{})
+	s := newServer(metrics.NewServerMetrics())
 	// Register reflection server on gRPC server.
 	reflection.Register(s)
 	go func() {
@@ -47,7 +48,7 @@ func TestServer_AddProduct(t *testing.T) {
 	// Contact the server and print out its response.
 	name := "Sumsung S10"
 	description := "Samsung Galaxy S10 is the latest smart phone, launched in February 2019"
-	price := float32(700.0)
+	price := amount.New("USD", 700, 0)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 	r, err := c.AddProduct(ctx, &pb.Product{Name: name, Description: description, Price: price})
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/golang/protobuf/proto"
+	money "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc"
+	"grpc-up-and-running/common/validation"
+	pb "productinfo/service/ecommerce"
+)
+
+// The validation rules of the request messages, by the full name of the message.
+// All the rules of a message are checked, every violated field is reported in one BadRequest.
+var validationRules = validation.Rules{
+	proto.MessageName(&pb.Product{}): {
+		validation.StringField("name", func(m proto.Message) string { return m.(*pb.Product).Name },
+			validation.Required(), validation.MaxLength(100)),
+		validation.StringField("description", func(m proto.Message) string { return m.(*pb.Product).Description },
+			validation.MaxLength(1000)),
+		validation.MoneyField("price", func(m proto.Message) *money.Money { return m.(*pb.Product).Price },
+			validation.Positive()),
+	},
+}
+
+// Validate the message by the rules of its type, the message without rules is always valid.
+// Returns an InvalidArgument error with the BadRequest details listing every violated field.
+func validate(m interface{}) error {
+	return validationRules.Validate(m)
+}
+
+// Unary Interceptor (server-side)
+// This interceptor validates the request before running the remote method.
+func validationUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := validate(req); err != nil {
+		log.Printf("Invalid request of %s : %v", info.FullMethod, err)
+		return nil, err
+	}
+	return handler(ctx, req)
+}